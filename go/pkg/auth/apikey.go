@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type apiKeyEntry struct {
+	hash   [sha256.Size]byte
+	claims jwt.MapClaims
+}
+
+// APIKeyAuthenticator authenticates static API keys, each mapped to the claims of the
+// machine identity it was issued to
+type APIKeyAuthenticator struct {
+	keys []apiKeyEntry
+}
+
+var _ Authenticator = &APIKeyAuthenticator{}
+
+// NewAPIKeyAuthenticator creates an authenticator accepting the given API keys.
+// The claims of a key should at least carry a "sub" identifying its holder.
+func NewAPIKeyAuthenticator(keys map[string]jwt.MapClaims) *APIKeyAuthenticator {
+	a := &APIKeyAuthenticator{keys: make([]apiKeyEntry, 0, len(keys))}
+	for key, claims := range keys {
+		a.keys = append(a.keys, apiKeyEntry{hash: sha256.Sum256([]byte(key)), claims: copyClaims(claims)})
+	}
+	return a
+}
+
+// Authenticate returns a deep copy of the claims of the given API key.
+// Every configured key is compared in constant time so the timing does not reveal a match.
+func (a *APIKeyAuthenticator) Authenticate(key string) (jwt.MapClaims, error) {
+	hash := sha256.Sum256([]byte(key))
+	var found jwt.MapClaims
+	for _, entry := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], entry.hash[:]) == 1 {
+			found = entry.claims
+		}
+	}
+	if found == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "API key is not valid")
+	}
+	return copyClaims(found), nil
+}
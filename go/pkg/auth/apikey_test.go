@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+	"testing"
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	authenticator := NewAPIKeyAuthenticator(map[string]jwt.MapClaims{
+		"key-1": {"sub": "service-1"},
+		"key-2": {"sub": "service-2", "realm_access": map[string]interface{}{"roles": []interface{}{"reader"}}},
+	})
+
+	claims, err := authenticator.Authenticate("key-2")
+	assert.NilError(t, err)
+	assert.Equal(t, "service-2", claims["sub"])
+
+	// The returned claims are a deep copy
+	claims["sub"] = "changed"
+	claims["realm_access"].(map[string]interface{})["roles"].([]interface{})[0] = "admin"
+	claims, err = authenticator.Authenticate("key-2")
+	assert.NilError(t, err)
+	assert.Equal(t, "service-2", claims["sub"])
+	assert.DeepEqual(t, map[string]interface{}{"roles": []interface{}{"reader"}}, claims["realm_access"])
+
+	_, err = authenticator.Authenticate("key-3")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = authenticator.Authenticate("")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,360 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package authtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/open-edge-platform/orch-library/go/pkg/keycloak"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultKeycloakRealm is the realm of the fake Keycloak unless WithRealm is given
+	DefaultKeycloakRealm = "master"
+	// DefaultAccessTokenLifetime is the default lifetime of the access tokens issued by the fake Keycloak
+	DefaultAccessTokenLifetime = 5 * time.Minute
+	// DefaultRefreshTokenLifetime is the default lifetime of the refresh tokens issued by the fake Keycloak
+	DefaultRefreshTokenLifetime = 30 * time.Minute
+)
+
+// KeycloakOption configures a fake Keycloak
+type KeycloakOption func(*Keycloak)
+
+// WithRealm sets the realm served by the fake Keycloak
+func WithRealm(realm string) KeycloakOption {
+	return func(k *Keycloak) {
+		k.realm = realm
+	}
+}
+
+// WithAccessTokenLifetime sets the lifetime of the issued access tokens
+func WithAccessTokenLifetime(lifetime time.Duration) KeycloakOption {
+	return func(k *Keycloak) {
+		k.accessLifetime = lifetime
+	}
+}
+
+// WithRefreshTokenLifetime sets the lifetime of the issued refresh tokens; 0 issues no refresh tokens
+func WithRefreshTokenLifetime(lifetime time.Duration) KeycloakOption {
+	return func(k *Keycloak) {
+		k.refreshLifetime = lifetime
+	}
+}
+
+type keycloakSession struct {
+	clientID string
+	subject  string
+	expires  time.Time
+}
+
+// Keycloak is a fake Keycloak server of one realm. Its token endpoint supports the password,
+// client credentials and refresh token grants, and its admin API serves the clients of the realm
+// and their secrets to the users and service accounts allowed with AllowAdmin.
+// The issued tokens are JWTs signed with a random HMAC key; they are not meant to be verified.
+type Keycloak struct {
+	*server
+	realm           string
+	accessLifetime  time.Duration
+	refreshLifetime time.Duration
+	key             []byte
+
+	mu       sync.Mutex
+	nextID   int
+	clients  map[string]*keycloak.Client
+	users    map[string]string
+	admins   map[string]bool
+	sessions map[string]*keycloakSession
+	access   map[string]*keycloakSession
+}
+
+// NewKeycloak starts a fake Keycloak with the public keycloak.DefaultAdminCLIClient client.
+// Close it when the test is done.
+func NewKeycloak(opts ...KeycloakOption) *Keycloak {
+	k := &Keycloak{
+		realm:           DefaultKeycloakRealm,
+		accessLifetime:  DefaultAccessTokenLifetime,
+		refreshLifetime: DefaultRefreshTokenLifetime,
+		key:             make([]byte, 32),
+		clients:         map[string]*keycloak.Client{},
+		users:           map[string]string{},
+		admins:          map[string]bool{},
+		sessions:        map[string]*keycloakSession{},
+		access:          map[string]*keycloakSession{},
+	}
+	_, _ = rand.Read(k.key)
+	for _, opt := range opts {
+		opt(k)
+	}
+	k.addClientLocked(keycloak.Client{ClientID: keycloak.DefaultAdminCLIClient, PublicClient: keycloak.Bool(true)})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /realms/{realm}/protocol/openid-connect/token", k.withRealm(k.handleToken))
+	mux.HandleFunc("GET /admin/realms/{realm}/clients", k.withAdmin(k.handleListClients))
+	mux.HandleFunc("POST /admin/realms/{realm}/clients", k.withAdmin(k.handleCreateClient))
+	mux.HandleFunc("GET /admin/realms/{realm}/clients/{id}", k.withAdmin(k.handleGetClient))
+	mux.HandleFunc("GET /admin/realms/{realm}/clients/{id}/client-secret", k.withAdmin(k.handleClientSecret))
+	mux.HandleFunc("POST /admin/realms/{realm}/clients/{id}/client-secret", k.withAdmin(k.handleClientSecret))
+	k.server = newServer(mux)
+	return k
+}
+
+// Realm returns the realm served by the fake
+func (k *Keycloak) Realm() string {
+	return k.realm
+}
+
+// AddClient adds a client to the realm and returns its ID. Confidential clients without a secret get a random one.
+func (k *Keycloak) AddClient(client keycloak.Client) string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.addClientLocked(client)
+}
+
+func (k *Keycloak) addClientLocked(client keycloak.Client) string {
+	if client.ID == "" {
+		k.nextID++
+		client.ID = fmt.Sprintf("00000000-0000-0000-0000-%012d", k.nextID)
+	}
+	if client.Secret == "" && (client.PublicClient == nil || !*client.PublicClient) {
+		client.Secret = randomSecret()
+	}
+	k.clients[client.ID] = &client
+	return client.ID
+}
+
+// AddUser adds a user who can log in with the password grant
+func (k *Keycloak) AddUser(username string, password string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.users[username] = password
+}
+
+// AllowAdmin allows a user, or the service account of a client given by its client ID, to call the admin API
+func (k *Keycloak) AllowAdmin(usernameOrClientID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.admins[usernameOrClientID] = true
+}
+
+// ClientSecret returns the current secret of the client with the given client ID
+func (k *Keycloak) ClientSecret(clientID string) string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if client := k.clientLocked(clientID); client != nil {
+		return client.Secret
+	}
+	return ""
+}
+
+// RevokeSessions invalidates all issued access and refresh tokens
+func (k *Keycloak) RevokeSessions() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sessions = map[string]*keycloakSession{}
+	k.access = map[string]*keycloakSession{}
+}
+
+func (k *Keycloak) clientLocked(clientID string) *keycloak.Client {
+	for _, client := range k.clients {
+		if client.ClientID == clientID {
+			return client
+		}
+	}
+	return nil
+}
+
+func randomSecret() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code string, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+func (k *Keycloak) withRealm(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("realm") != k.realm {
+			writeOAuthError(w, http.StatusNotFound, "Realm does not exist", "")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (k *Keycloak) withAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return k.withRealm(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		k.mu.Lock()
+		session, ok := k.access[token]
+		if !found || !ok || !time.Now().Before(session.expires) {
+			k.mu.Unlock()
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "HTTP 401 Unauthorized"})
+			return
+		}
+		admin := k.admins[session.subject] || (strings.HasPrefix(session.subject, "service-account-") &&
+			k.admins[strings.TrimPrefix(session.subject, "service-account-")])
+		k.mu.Unlock()
+		if !admin {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "HTTP 403 Forbidden"})
+			return
+		}
+		handler(w, r)
+	})
+}
+
+func (k *Keycloak) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	clientID, clientSecret, basic := r.BasicAuth()
+	if !basic {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	client := k.clientLocked(clientID)
+	public := client != nil && client.PublicClient != nil && *client.PublicClient
+	if client == nil || (!public && client.Secret != clientSecret) {
+		writeOAuthError(w, http.StatusUnauthorized, "unauthorized_client", "Invalid client or Invalid client credentials")
+		return
+	}
+
+	var subject string
+	switch r.PostForm.Get("grant_type") {
+	case "password":
+		username := r.PostForm.Get("username")
+		password, ok := k.users[username]
+		if !ok || password != r.PostForm.Get("password") {
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_grant", "Invalid user credentials")
+			return
+		}
+		subject = username
+	case "client_credentials":
+		if public || client.ServiceAccountsEnabled == nil || !*client.ServiceAccountsEnabled {
+			writeOAuthError(w, http.StatusUnauthorized, "unauthorized_client", "Client not enabled to retrieve service account")
+			return
+		}
+		subject = "service-account-" + clientID
+	case "refresh_token":
+		session, ok := k.sessions[r.PostForm.Get("refresh_token")]
+		if !ok || session.clientID != clientID || !time.Now().Before(session.expires) {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "Invalid refresh token")
+			return
+		}
+		delete(k.sessions, r.PostForm.Get("refresh_token"))
+		subject = session.subject
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "Unsupported grant_type")
+		return
+	}
+	writeJSON(w, http.StatusOK, k.issueTokensLocked(clientID, subject))
+}
+
+func (k *Keycloak) issueTokensLocked(clientID string, subject string) map[string]interface{} {
+	now := time.Now()
+	issuer := fmt.Sprintf("%s/realms/%s", k.URL, k.realm)
+	sign := func(typ string, expires time.Time) string {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss":                issuer,
+			"sub":                subject,
+			"azp":                clientID,
+			"typ":                typ,
+			"preferred_username": subject,
+			"iat":                now.Unix(),
+			"exp":                expires.Unix(),
+			"jti":                randomSecret(),
+		}).SignedString(k.key)
+		return token
+	}
+
+	accessExpires := now.Add(k.accessLifetime)
+	accessToken := sign("Bearer", accessExpires)
+	k.access[accessToken] = &keycloakSession{clientID: clientID, subject: subject, expires: accessExpires}
+	resp := map[string]interface{}{
+		"access_token": accessToken,
+		"expires_in":   int(k.accessLifetime.Seconds()),
+		"token_type":   "Bearer",
+	}
+	if k.refreshLifetime > 0 {
+		refreshExpires := now.Add(k.refreshLifetime)
+		refreshToken := sign("Refresh", refreshExpires)
+		k.sessions[refreshToken] = &keycloakSession{clientID: clientID, subject: subject, expires: refreshExpires}
+		resp["refresh_token"] = refreshToken
+		resp["refresh_expires_in"] = int(k.refreshLifetime.Seconds())
+	}
+	return resp
+}
+
+func (k *Keycloak) handleListClients(w http.ResponseWriter, r *http.Request) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	clients := []keycloak.Client{}
+	for _, client := range k.clients {
+		if clientID := r.URL.Query().Get("clientId"); clientID == "" || client.ClientID == clientID {
+			clients = append(clients, withoutSecret(client))
+		}
+	}
+	writeJSON(w, http.StatusOK, clients)
+}
+
+func (k *Keycloak) handleCreateClient(w http.ResponseWriter, r *http.Request) {
+	client := keycloak.Client{}
+	if err := json.NewDecoder(r.Body).Decode(&client); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"errorMessage": err.Error()})
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if client.ClientID == "" || k.clientLocked(client.ClientID) != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"errorMessage": fmt.Sprintf("Client %s already exists", client.ClientID)})
+		return
+	}
+	client.ID = ""
+	id := k.addClientLocked(client)
+	w.Header().Set("Location", fmt.Sprintf("%s/admin/realms/%s/clients/%s", k.URL, k.realm, id))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (k *Keycloak) handleGetClient(w http.ResponseWriter, r *http.Request) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	client, ok := k.clients[r.PathValue("id")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Could not find client"})
+		return
+	}
+	writeJSON(w, http.StatusOK, withoutSecret(client))
+}
+
+func (k *Keycloak) handleClientSecret(w http.ResponseWriter, r *http.Request) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	client, ok := k.clients[r.PathValue("id")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Could not find client"})
+		return
+	}
+	if r.Method == http.MethodPost {
+		client.Secret = randomSecret()
+	}
+	writeJSON(w, http.StatusOK, keycloak.Credential{Type: "secret", Value: client.Secret})
+}
+
+// withoutSecret returns a copy of the client as listed by Keycloak, which does not include secrets
+func withoutSecret(client *keycloak.Client) keycloak.Client {
+	listed := *client
+	listed.Secret = ""
+	return listed
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package authtest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth/authtest"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"github.com/open-edge-platform/orch-library/go/pkg/keycloak"
+	"gotest.tools/assert"
+)
+
+func newKeycloak() *authtest.Keycloak {
+	k := authtest.NewKeycloak()
+	k.AddClient(keycloak.Client{ClientID: auth.DefaultKeycloakAdminClient, PublicClient: keycloak.Bool(true)})
+	k.AddClient(keycloak.Client{ClientID: auth.DefaultM2MClient, ServiceAccountsEnabled: keycloak.Bool(true)})
+	k.AddUser("admin", "pass")
+	k.AllowAdmin("admin")
+	k.AddUser("user", "pass")
+	return k
+}
+
+func TestKeycloak_M2MToken(t *testing.T) {
+	k := newKeycloak()
+	defer k.Close()
+	vault := authtest.NewVault()
+	defer vault.Close()
+	t.Setenv("USE_M2M_TOKEN", "true")
+	v, err := auth.NewVaultAuth(k.URL, vault.URL, "test-svc",
+		auth.WithServiceAccountTokenFile(authtest.WriteServiceAccountToken(t, "sa-token")))
+	assert.NilError(t, err)
+	ctx := context.Background()
+
+	_, err = v.CreateClientSecret(ctx, "user", "pass")
+	assert.Assert(t, errors.IsForbidden(err))
+	_, err = v.CreateClientSecret(ctx, "admin", "wrong")
+	assert.Assert(t, errors.IsUnauthorized(err))
+
+	secret, err := v.CreateClientSecret(ctx, "admin", "pass")
+	assert.NilError(t, err)
+	assert.Equal(t, k.ClientSecret(auth.DefaultM2MClient), secret)
+	stored, _, ok := vault.Secret(authtest.DefaultVaultKVMount, auth.DefaultM2MSecretPath)
+	assert.Assert(t, ok)
+	assert.Equal(t, secret, stored["client_secret"])
+
+	token, err := v.GetM2MToken(ctx)
+	assert.NilError(t, err)
+	assert.Assert(t, token != "")
+	token2, err := v.GetM2MToken(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, token, token2)
+	assert.Equal(t, 4, k.Requests(http.MethodPost, "/realms/master/protocol/openid-connect/token"))
+}
+
+func TestKeycloak_AdminClient(t *testing.T) {
+	k := newKeycloak()
+	defer k.Close()
+	k.AddClient(keycloak.Client{ClientID: "provisioner", Secret: "sa-secret", ServiceAccountsEnabled: keycloak.Bool(true)})
+	k.AllowAdmin("provisioner")
+	ctx := context.Background()
+
+	admin, err := keycloak.NewAdminClient(k.URL, k.Realm(), keycloak.WithClientCredentialsLogin("provisioner", "sa-secret"))
+	assert.NilError(t, err)
+	client, err := admin.GetClientByClientID(ctx, auth.DefaultM2MClient)
+	assert.NilError(t, err)
+	assert.Equal(t, "", client.Secret)
+	secret, err := admin.RegenerateClientSecret(ctx, client.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, k.ClientSecret(auth.DefaultM2MClient), secret)
+
+	id, err := admin.CreateClient(ctx, keycloak.Client{ClientID: "app"})
+	assert.NilError(t, err)
+	created, err := admin.GetClient(ctx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, "app", created.ClientID)
+	_, err = admin.CreateClient(ctx, keycloak.Client{ClientID: "app"})
+	assert.Assert(t, errors.IsConflict(err))
+
+	k.InjectFault(authtest.Fault{Method: http.MethodGet, Path: "/admin/", Status: http.StatusBadGateway, Times: 1})
+	_, err = admin.ListClients(ctx)
+	assert.Assert(t, errors.IsUnavailable(err))
+
+	// Revoked access tokens are rejected until the admin client logs in again
+	k.RevokeSessions()
+	_, err = admin.ListClients(ctx)
+	assert.Assert(t, errors.IsUnauthorized(err))
+}
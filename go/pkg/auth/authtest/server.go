@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+// Package authtest provides in-process fakes of the Vault and Keycloak servers used by the auth package,
+// so that services can run integration tests of their authentication flows offline. The fakes speak the
+// real HTTP APIs, hold state that tests can seed and inspect, and can be told to fail requests.
+package authtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault makes a fake fail the requests it matches
+type Fault struct {
+	// Method is the method of the matched requests; empty matches all methods
+	Method string
+	// Path is a prefix of the paths of the matched requests; empty matches all paths
+	Path string
+	// Status is the status of the failed responses; 0 only delays the requests
+	Status int
+	// Body is the body of the failed responses
+	Body string
+	// Delay is how long the matched requests wait before they are answered
+	Delay time.Duration
+	// Times is how many requests fail; 0 fails all matched requests until ClearFaults
+	Times int
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	return (f.Method == "" || f.Method == r.Method) && strings.HasPrefix(r.URL.Path, f.Path)
+}
+
+// server is the httptest server shared by the fakes, counting requests and injecting faults
+type server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	faults   []*Fault
+	requests map[string]int
+}
+
+func newServer(handler http.Handler) *server {
+	s := &server{requests: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fault := s.record(r)
+		if fault != nil {
+			if fault.Delay > 0 {
+				select {
+				case <-time.After(fault.Delay):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			if fault.Status != 0 {
+				w.WriteHeader(fault.Status)
+				_, _ = w.Write([]byte(fault.Body))
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// record counts the request and returns the first fault matching it
+func (s *server) record(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.Method+" "+r.URL.Path]++
+	for i, f := range s.faults {
+		if !f.matches(r) {
+			continue
+		}
+		fault := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return &fault
+	}
+	return nil
+}
+
+// InjectFault makes the fake fail the requests matched by the fault.
+// Faults are matched in the order they were injected.
+func (s *server) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes all injected faults
+func (s *server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests returns how many requests with the method and path the fake received, including failed requests
+func (s *server) Requests(method string, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,491 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package authtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// DefaultVaultLeaseDuration is the default lease of the tokens issued by the fake Vault
+	DefaultVaultLeaseDuration = time.Hour
+	// DefaultVaultKVMount is the KV version 2 mount of the fake Vault unless WithKVMounts is given
+	DefaultVaultKVMount = "secret"
+)
+
+// VaultOption configures a fake Vault
+type VaultOption func(*Vault)
+
+// WithLeaseDuration sets the lease of the tokens issued by logins and renewals
+func WithLeaseDuration(lease time.Duration) VaultOption {
+	return func(v *Vault) {
+		v.lease = lease
+	}
+}
+
+// WithKVMounts sets the mounts of the KV version 2 secrets engine, replacing DefaultVaultKVMount
+func WithKVMounts(mounts ...string) VaultOption {
+	return func(v *Vault) {
+		v.mounts = map[string]bool{}
+		for _, mount := range mounts {
+			v.mounts[strings.Trim(mount, "/")] = true
+		}
+	}
+}
+
+// WithKubernetesRole allows the Kubernetes login of a role with the given service account token.
+// Without this option, the login of any role with a non empty token succeeds.
+func WithKubernetesRole(role string, serviceAccountToken string) VaultOption {
+	return func(v *Vault) {
+		v.k8sRoles[role] = serviceAccountToken
+	}
+}
+
+// WithAppRole allows the AppRole login with the role and secret ID
+func WithAppRole(roleID string, secretID string) VaultOption {
+	return func(v *Vault) {
+		v.appRoles[roleID] = secretID
+	}
+}
+
+type vaultToken struct {
+	expires   time.Time
+	renewable bool
+}
+
+type kvVersion struct {
+	data      map[string]interface{}
+	created   time.Time
+	deleted   time.Time
+	destroyed bool
+}
+
+// Vault is a fake Vault server supporting the Kubernetes and AppRole logins, the renewal, lookup and
+// revocation of the issued tokens, and the KV version 2 secrets engine. KV requests need a valid token.
+type Vault struct {
+	*server
+	lease    time.Duration
+	mounts   map[string]bool
+	k8sRoles map[string]string
+	appRoles map[string]string
+
+	mu      sync.Mutex
+	tokens  map[string]*vaultToken
+	secrets map[string][]*kvVersion
+}
+
+// NewVault starts a fake Vault. Close it when the test is done.
+func NewVault(opts ...VaultOption) *Vault {
+	v := &Vault{
+		lease:    DefaultVaultLeaseDuration,
+		mounts:   map[string]bool{DefaultVaultKVMount: true},
+		k8sRoles: map[string]string{},
+		appRoles: map[string]string{},
+		tokens:   map[string]*vaultToken{},
+		secrets:  map[string][]*kvVersion{},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/auth/kubernetes/login", v.handleKubernetesLogin)
+	mux.HandleFunc("POST /v1/auth/approle/login", v.handleAppRoleLogin)
+	mux.HandleFunc("POST /v1/auth/token/renew-self", v.handleRenewSelf)
+	mux.HandleFunc("GET /v1/auth/token/lookup-self", v.handleLookupSelf)
+	mux.HandleFunc("POST /v1/auth/token/revoke-self", v.handleRevokeSelf)
+	mux.HandleFunc("/v1/{mount}/data/{path...}", v.withToken(v.handleKVData))
+	mux.HandleFunc("/v1/{mount}/metadata/{path...}", v.withToken(v.handleKVMetadata))
+	mux.HandleFunc("POST /v1/{mount}/delete/{path...}", v.withToken(v.handleKVDelete))
+	v.server = newServer(mux)
+	return v
+}
+
+// AddToken adds a token with the given time to live, for example for services logging in with a
+// pre-issued token. A zero ttl uses the lease duration of the fake.
+func (v *Vault) AddToken(token string, ttl time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if ttl == 0 {
+		ttl = v.lease
+	}
+	v.tokens[token] = &vaultToken{expires: time.Now().Add(ttl), renewable: true}
+}
+
+// ActiveTokens returns how many issued tokens are neither expired nor revoked
+func (v *Vault) ActiveTokens() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	active := 0
+	for _, t := range v.tokens {
+		if time.Now().Before(t.expires) {
+			active++
+		}
+	}
+	return active
+}
+
+// RevokeTokens revokes all issued tokens, as if their leases ran out
+func (v *Vault) RevokeTokens() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tokens = map[string]*vaultToken{}
+}
+
+// SetSecret writes a new version of the secret at path in the KV mount and returns the version
+func (v *Vault) SetSecret(mount string, path string, data map[string]interface{}) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key := kvKey(mount, path)
+	v.secrets[key] = append(v.secrets[key], &kvVersion{data: data, created: time.Now()})
+	return len(v.secrets[key])
+}
+
+// Secret returns the current version of the secret at path in the KV mount and its version number,
+// or false if there is no secret or its current version is deleted
+func (v *Vault) Secret(mount string, path string) (map[string]interface{}, int, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	versions := v.secrets[kvKey(mount, path)]
+	if len(versions) == 0 {
+		return nil, 0, false
+	}
+	current := versions[len(versions)-1]
+	if !current.deleted.IsZero() || current.destroyed {
+		return nil, len(versions), false
+	}
+	return current.data, len(versions), true
+}
+
+// WriteServiceAccountToken writes a Kubernetes service account token to a file in a temporary
+// directory of the test and returns its path, for the auth.WithServiceAccountTokenFile option
+func WriteServiceAccountToken(t testing.TB, token string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+		t.Fatalf("unable to write service account token: %v", err)
+	}
+	return path
+}
+
+func kvKey(mount string, path string) string {
+	return strings.Trim(mount, "/") + "/" + strings.Trim(path, "/")
+}
+
+func writeVaultError(w http.ResponseWriter, status int, errs ...string) {
+	if errs == nil {
+		errs = []string{}
+	}
+	writeJSON(w, status, map[string]interface{}{"errors": errs})
+}
+
+func (v *Vault) issueToken(w http.ResponseWriter) {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	token := "hvs." + hex.EncodeToString(buf)
+
+	v.mu.Lock()
+	v.tokens[token] = &vaultToken{expires: time.Now().Add(v.lease), renewable: true}
+	v.mu.Unlock()
+	v.writeAuth(w, token)
+}
+
+func (v *Vault) writeAuth(w http.ResponseWriter, token string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"auth": map[string]interface{}{
+			"client_token":   token,
+			"lease_duration": int(v.lease.Seconds()),
+			"renewable":      true,
+		},
+	})
+}
+
+func (v *Vault) handleKubernetesLogin(w http.ResponseWriter, r *http.Request) {
+	var login struct {
+		JWT  string `json:"jwt"`
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login.JWT == "" || login.Role == "" {
+		writeVaultError(w, http.StatusBadRequest, "missing jwt or role")
+		return
+	}
+	if len(v.k8sRoles) > 0 {
+		if jwt, ok := v.k8sRoles[login.Role]; !ok || jwt != login.JWT {
+			writeVaultError(w, http.StatusForbidden, "permission denied")
+			return
+		}
+	}
+	v.issueToken(w)
+}
+
+func (v *Vault) handleAppRoleLogin(w http.ResponseWriter, r *http.Request) {
+	var login struct {
+		RoleID   string `json:"role_id"`
+		SecretID string `json:"secret_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+		writeVaultError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if secretID, ok := v.appRoles[login.RoleID]; !ok || secretID != login.SecretID {
+		writeVaultError(w, http.StatusBadRequest, "invalid role or secret ID")
+		return
+	}
+	v.issueToken(w)
+}
+
+// token returns the valid token of the request
+func (v *Vault) token(r *http.Request) (string, *vaultToken, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	token := r.Header.Get("X-Vault-Token")
+	t, ok := v.tokens[token]
+	if !ok || !time.Now().Before(t.expires) {
+		return "", nil, false
+	}
+	return token, t, true
+}
+
+func (v *Vault) withToken(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := v.token(r); !ok {
+			writeVaultError(w, http.StatusForbidden, "permission denied")
+			return
+		}
+		if !v.mounts[r.PathValue("mount")] {
+			writeVaultError(w, http.StatusNotFound, "no handler for route "+r.URL.Path)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (v *Vault) handleRenewSelf(w http.ResponseWriter, r *http.Request) {
+	token, t, ok := v.token(r)
+	if !ok {
+		writeVaultError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	v.mu.Lock()
+	t.expires = time.Now().Add(v.lease)
+	v.mu.Unlock()
+	v.writeAuth(w, token)
+}
+
+func (v *Vault) handleLookupSelf(w http.ResponseWriter, r *http.Request) {
+	_, t, ok := v.token(r)
+	if !ok {
+		writeVaultError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"ttl":       int(time.Until(t.expires).Seconds()),
+			"renewable": t.renewable,
+		},
+	})
+}
+
+func (v *Vault) handleRevokeSelf(w http.ResponseWriter, r *http.Request) {
+	token, _, ok := v.token(r)
+	if !ok {
+		writeVaultError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	v.mu.Lock()
+	delete(v.tokens, token)
+	v.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func versionMetadata(version int, kv *kvVersion) map[string]interface{} {
+	deleted := ""
+	if !kv.deleted.IsZero() {
+		deleted = kv.deleted.Format(time.RFC3339Nano)
+	}
+	return map[string]interface{}{
+		"version":         version,
+		"created_time":    kv.created.Format(time.RFC3339Nano),
+		"deletion_time":   deleted,
+		"destroyed":       kv.destroyed,
+		"custom_metadata": nil,
+	}
+}
+
+func (v *Vault) handleKVData(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key := kvKey(r.PathValue("mount"), r.PathValue("path"))
+	versions := v.secrets[key]
+
+	switch r.Method {
+	case http.MethodGet:
+		version := len(versions)
+		if q := r.URL.Query().Get("version"); q != "" && q != "0" {
+			version, _ = strconv.Atoi(q)
+		}
+		if version <= 0 || version > len(versions) || !versions[version-1].deleted.IsZero() || versions[version-1].destroyed {
+			writeVaultError(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     versions[version-1].data,
+				"metadata": versionMetadata(version, versions[version-1]),
+			},
+		})
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		var body struct {
+			Data    map[string]interface{} `json:"data"`
+			Options struct {
+				CAS *int `json:"cas"`
+			} `json:"options"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeVaultError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if body.Options.CAS != nil && *body.Options.CAS != len(versions) {
+			writeVaultError(w, http.StatusBadRequest, "check-and-set parameter did not match the current version")
+			return
+		}
+		data := body.Data
+		if r.Method == http.MethodPatch {
+			if len(versions) == 0 || !versions[len(versions)-1].deleted.IsZero() || versions[len(versions)-1].destroyed {
+				writeVaultError(w, http.StatusNotFound)
+				return
+			}
+			data = mergePatch(versions[len(versions)-1].data, body.Data)
+		}
+		kv := &kvVersion{data: data, created: time.Now()}
+		v.secrets[key] = append(versions, kv)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": versionMetadata(len(v.secrets[key]), kv)})
+	case http.MethodDelete:
+		if len(versions) > 0 {
+			versions[len(versions)-1].deleted = time.Now()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeVaultError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+func (v *Vault) handleKVMetadata(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	mount := r.PathValue("mount")
+	path := r.PathValue("path")
+
+	switch {
+	case r.Method == "LIST" || (r.Method == http.MethodGet && r.URL.Query().Get("list") == "true"):
+		keys := v.listKeys(mount, path)
+		if len(keys) == 0 {
+			writeVaultError(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+	case r.Method == http.MethodGet:
+		versions := v.secrets[kvKey(mount, path)]
+		if len(versions) == 0 {
+			writeVaultError(w, http.StatusNotFound)
+			return
+		}
+		metadata := map[string]interface{}{}
+		for i, kv := range versions {
+			metadata[strconv.Itoa(i+1)] = versionMetadata(i+1, kv)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"current_version": len(versions),
+				"oldest_version":  1,
+				"created_time":    versions[0].created.Format(time.RFC3339Nano),
+				"updated_time":    versions[len(versions)-1].created.Format(time.RFC3339Nano),
+				"versions":        metadata,
+			},
+		})
+	case r.Method == http.MethodDelete:
+		delete(v.secrets, kvKey(mount, path))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeVaultError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+// listKeys returns the secrets and folders directly below path, folders ending with "/"
+func (v *Vault) listKeys(mount string, path string) []string {
+	prefix := kvKey(mount, path)
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	found := map[string]bool{}
+	for key, versions := range v.secrets {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok || len(versions) == 0 {
+			continue
+		}
+		if folder, _, isFolder := strings.Cut(rest, "/"); isFolder {
+			found[folder+"/"] = true
+		} else {
+			found[rest] = true
+		}
+	}
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *Vault) handleKVDelete(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Versions []int `json:"versions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeVaultError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	versions := v.secrets[kvKey(r.PathValue("mount"), r.PathValue("path"))]
+	for _, version := range body.Versions {
+		if version > 0 && version <= len(versions) {
+			versions[version-1].deleted = time.Now()
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to the data of a secret
+func mergePatch(data map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(data))
+	for k, value := range data {
+		merged[k] = value
+	}
+	for k, value := range patch {
+		switch value := value.(type) {
+		case nil:
+			delete(merged, k)
+		case map[string]interface{}:
+			existing, _ := merged[k].(map[string]interface{})
+			merged[k] = mergePatch(existing, value)
+		default:
+			merged[k] = value
+		}
+	}
+	return merged
+}
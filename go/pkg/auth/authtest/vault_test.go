@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package authtest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth/authtest"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"gotest.tools/assert"
+)
+
+type testSecret struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+}
+
+func newVaultAuth(t *testing.T, vault *authtest.Vault, opts ...auth.VaultAuthOption) auth.VaultAuth {
+	opts = append([]auth.VaultAuthOption{
+		auth.WithServiceAccountTokenFile(authtest.WriteServiceAccountToken(t, "sa-token")),
+		auth.WithVaultKVMount("kv"),
+	}, opts...)
+	v, err := auth.NewVaultAuth("http://keycloak.invalid", vault.URL, "test-svc", opts...)
+	assert.NilError(t, err)
+	return v
+}
+
+func TestVault_KV(t *testing.T) {
+	vault := authtest.NewVault(authtest.WithKubernetesRole("test-svc", "sa-token"), authtest.WithKVMounts("kv"))
+	defer vault.Close()
+	v := newVaultAuth(t, vault)
+	ctx := context.Background()
+	kv := v.KV("")
+
+	vault.SetSecret("kv", "app/db", map[string]interface{}{"username": "seeded"})
+	secret, metadata, err := auth.ReadVaultKV[testSecret](ctx, kv, "app/db")
+	assert.NilError(t, err)
+	assert.Equal(t, "seeded", secret.Username)
+	assert.Equal(t, 1, metadata.Version)
+
+	_, err = kv.Write(ctx, "app/db", testSecret{Username: "app", Password: "p1"}, auth.WithCAS(1))
+	assert.NilError(t, err)
+	_, err = kv.Write(ctx, "app/db", testSecret{Username: "app"}, auth.WithCAS(1))
+	assert.Assert(t, errors.IsConflict(err))
+	_, err = kv.Patch(ctx, "app/db", map[string]interface{}{"password": "p2"})
+	assert.NilError(t, err)
+
+	data, version, ok := vault.Secret("kv", "app/db")
+	assert.Assert(t, ok)
+	assert.Equal(t, 3, version)
+	assert.DeepEqual(t, map[string]interface{}{"username": "app", "password": "p2"}, data)
+
+	_, err = kv.Write(ctx, "app/cache", testSecret{Username: "cache"})
+	assert.NilError(t, err)
+	keys, err := kv.List(ctx, "app")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"cache", "db"}, keys)
+
+	assert.NilError(t, kv.Delete(ctx, "app/db"))
+	_, _, err = auth.ReadVaultKV[testSecret](ctx, kv, "app/db")
+	assert.Assert(t, errors.IsNotFound(err))
+
+	assert.Equal(t, 1, vault.Requests(http.MethodPost, "/v1/auth/kubernetes/login"))
+	assert.NilError(t, v.Logout(ctx))
+	assert.Equal(t, 0, vault.ActiveTokens())
+	assert.Equal(t, 1, vault.Requests(http.MethodPost, "/v1/auth/token/revoke-self"))
+}
+
+func TestVault_Login(t *testing.T) {
+	vault := authtest.NewVault(authtest.WithKubernetesRole("other-svc", "sa-token"),
+		authtest.WithAppRole("role", "secret"))
+	defer vault.Close()
+	ctx := context.Background()
+
+	_, err := newVaultAuth(t, vault).GetVaultToken(ctx)
+	assert.ErrorContains(t, err, "403")
+
+	token, err := newVaultAuth(t, vault, auth.WithAppRoleLogin("role", "secret")).GetVaultToken(ctx)
+	assert.NilError(t, err)
+	assert.Assert(t, token != "")
+
+	vault.AddToken("pre-issued", time.Minute)
+	t.Setenv(auth.VaultTokenEnv, "pre-issued")
+	v := newVaultAuth(t, vault, auth.WithTokenFromEnv(auth.VaultTokenEnv))
+	token, err = v.GetVaultToken(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, "pre-issued", token)
+	assert.Assert(t, v.LeaseStatus().Renewable)
+}
+
+func TestVault_Faults(t *testing.T) {
+	vault := authtest.NewVault(authtest.WithKVMounts("kv"))
+	defer vault.Close()
+	v := newVaultAuth(t, vault)
+	ctx := context.Background()
+	vault.SetSecret("kv", "app/db", map[string]interface{}{"username": "app"})
+
+	vault.InjectFault(authtest.Fault{Method: http.MethodGet, Path: "/v1/kv/data/", Status: http.StatusServiceUnavailable, Times: 1})
+	_, _, err := auth.ReadVaultKV[testSecret](ctx, v.KV(""), "app/db")
+	assert.Assert(t, errors.IsUnavailable(err))
+	_, _, err = auth.ReadVaultKV[testSecret](ctx, v.KV(""), "app/db")
+	assert.NilError(t, err)
+
+	vault.InjectFault(authtest.Fault{Path: "/v1/kv/", Status: http.StatusTooManyRequests})
+	for i := 0; i < 2; i++ {
+		_, _, err = auth.ReadVaultKV[testSecret](ctx, v.KV(""), "app/db")
+		assert.Assert(t, errors.IsUnavailable(err))
+	}
+	vault.ClearFaults()
+	_, _, err = auth.ReadVaultKV[testSecret](ctx, v.KV(""), "app/db")
+	assert.NilError(t, err)
+
+	// Tokens revoked behind the back of the client are rejected
+	vault.RevokeTokens()
+	_, _, err = auth.ReadVaultKV[testSecret](ctx, v.KV(""), "app/db")
+	assert.Assert(t, errors.IsForbidden(err))
+}
+
+func TestVault_LoginFaults(t *testing.T) {
+	vault := authtest.NewVault(authtest.WithKVMounts("kv"))
+	defer vault.Close()
+	vault.SetSecret("kv", "app/db", map[string]interface{}{"username": "app"})
+	ctx := context.Background()
+
+	// Only a rejection of the credentials is Unauthorized; callers retry when Vault is down
+	testCases := []struct {
+		status int
+		check  func(error) bool
+	}{
+		{status: http.StatusForbidden, check: errors.IsUnauthorized},
+		{status: http.StatusUnauthorized, check: errors.IsUnauthorized},
+		{status: http.StatusBadRequest, check: errors.IsInvalid},
+		{status: http.StatusInternalServerError, check: errors.IsUnavailable},
+		{status: http.StatusServiceUnavailable, check: errors.IsUnavailable},
+	}
+	for _, tc := range testCases {
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			vault.InjectFault(authtest.Fault{Path: "/v1/auth/", Status: tc.status, Times: 1})
+			_, _, err := auth.ReadVaultKV[testSecret](ctx, newVaultAuth(t, vault).KV(""), "app/db")
+			assert.Assert(t, tc.check(err), err)
+		})
+	}
+
+	// A KV request timing out is a Timeout
+	v := newVaultAuth(t, vault)
+	_, _, err := auth.ReadVaultKV[testSecret](ctx, v.KV(""), "app/db")
+	assert.NilError(t, err)
+	vault.InjectFault(authtest.Fault{Path: "/v1/kv/", Delay: time.Second, Times: 1})
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err = auth.ReadVaultKV[testSecret](timeoutCtx, v.KV(""), "app/db")
+	assert.Assert(t, errors.IsTimeout(err), err)
+
+	// A Vault that cannot be reached is Unavailable
+	vault.Close()
+	_, _, err = auth.ReadVaultKV[testSecret](ctx, newVaultAuth(t, vault).KV(""), "app/db")
+	assert.Assert(t, errors.IsUnavailable(err), err)
+}
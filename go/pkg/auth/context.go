@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type principalContextKey struct{}
+
+// NewContextWithPrincipal returns a context carrying the authenticated caller.
+// Unlike request metadata or headers, the principal cannot be set by the caller.
+func NewContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated caller, if the request was authenticated
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// NewContextWithClaims returns a context carrying the principal of the verified claims of the caller
+func NewContextWithClaims(ctx context.Context, claims jwt.MapClaims) context.Context {
+	return NewContextWithPrincipal(ctx, NewPrincipal(claims))
+}
+
+// ClaimsFromContext returns the verified claims of the caller, if the request was authenticated
+func ClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return principal.Claims, true
+}
@@ -0,0 +1,265 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultIntrospectionCacheSize is the default maximum number of cached introspection results
+	DefaultIntrospectionCacheSize = 1024
+
+	introspectionActiveField = "active"
+)
+
+// IntrospectionOption configures an IntrospectionAuthenticator
+type IntrospectionOption func(*IntrospectionAuthenticator)
+
+// WithIntrospectionEndpoint sets the introspection endpoint URL instead of discovering it
+func WithIntrospectionEndpoint(endpoint string) IntrospectionOption {
+	return func(a *IntrospectionAuthenticator) {
+		a.endpoint = endpoint
+	}
+}
+
+// WithIntrospectionDiscoveryURL sets the base URL of the OIDC discovery document
+// used to find the introspection endpoint
+func WithIntrospectionDiscoveryURL(discoveryURL string) IntrospectionOption {
+	return func(a *IntrospectionAuthenticator) {
+		a.discoveryURL = strings.TrimSuffix(discoveryURL, "/")
+	}
+}
+
+// WithIntrospectionHTTPClient sets the HTTP client used to call the introspection endpoint
+func WithIntrospectionHTTPClient(client *http.Client) IntrospectionOption {
+	return func(a *IntrospectionAuthenticator) {
+		a.httpClient = client
+	}
+}
+
+// WithIntrospectionCacheSize sets the maximum number of cached results; 0 disables the cache
+func WithIntrospectionCacheSize(size int) IntrospectionOption {
+	return func(a *IntrospectionAuthenticator) {
+		a.cacheSize = size
+	}
+}
+
+// WithIntrospectionMaxCacheTTL caps how long a result is cached, so that revocations are
+// noticed before the token expires. By default results are cached until the token "exp".
+func WithIntrospectionMaxCacheTTL(ttl time.Duration) IntrospectionOption {
+	return func(a *IntrospectionAuthenticator) {
+		a.maxCacheTTL = ttl
+	}
+}
+
+type introspectionResult struct {
+	claims  jwt.MapClaims
+	expires time.Time
+}
+
+// IntrospectionAuthenticator authenticates tokens, including opaque reference tokens, with the
+// OAuth 2.0 token introspection endpoint (RFC 7662) of the identity provider
+type IntrospectionAuthenticator struct {
+	clientID     string
+	clientSecret string
+	endpoint     string
+	discoveryURL string
+	httpClient   *http.Client
+	cacheSize    int
+	maxCacheTTL  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]introspectionResult
+}
+
+var _ Authenticator = &IntrospectionAuthenticator{}
+
+// NewIntrospectionAuthenticator creates an authenticator calling the introspection endpoint
+// with the given client credentials
+func NewIntrospectionAuthenticator(clientID string, clientSecret string, opts ...IntrospectionOption) (*IntrospectionAuthenticator, error) {
+	a := &IntrospectionAuthenticator{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		cacheSize:    DefaultIntrospectionCacheSize,
+		cache:        make(map[string]introspectionResult),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.httpClient == nil {
+		a.httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if a.endpoint == "" {
+		if a.discoveryURL == "" {
+			return nil, fmt.Errorf("either an introspection endpoint or a discovery URL is required")
+		}
+		provider, err := fetchProviderConfig(a.httpClient, a.discoveryURL)
+		if err != nil {
+			return nil, err
+		}
+		if provider.IntrospectionURL == "" {
+			return nil, fmt.Errorf("OIDC provider at %s has no introspection endpoint", a.discoveryURL)
+		}
+		a.endpoint = provider.IntrospectionURL
+	}
+	return a, nil
+}
+
+// Authenticate introspects the token and returns a copy of its claims if it is active
+func (a *IntrospectionAuthenticator) Authenticate(token string) (jwt.MapClaims, error) {
+	key := tokenCacheKey(token)
+	if claims, ok := a.cached(key); ok {
+		return copyClaims(claims), nil
+	}
+
+	claims, err := a.introspect(context.Background(), token)
+	if err != nil {
+		return nil, err
+	}
+	a.store(key, claims)
+	return copyClaims(claims), nil
+}
+
+func (a *IntrospectionAuthenticator) introspect(ctx context.Context, token string) (jwt.MapClaims, error) {
+	vals := url.Values{}
+	vals.Add("token", token)
+	vals.Add("token_type_hint", "access_token")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, strings.NewReader(vals.Encode()))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		log.Warnf("token introspection request failed %v", err)
+		return nil, status.Errorf(codes.Unavailable, "unable to reach token introspection endpoint")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Warnf("token introspection request failed %d", resp.StatusCode)
+		return nil, status.Errorf(codes.Unavailable, "token introspection request failed %d", resp.StatusCode)
+	}
+
+	claims := jwt.MapClaims{}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "unable to decode token introspection response: %v", err)
+	}
+	if active, _ := claims[introspectionActiveField].(bool); !active {
+		return nil, status.Errorf(codes.Unauthenticated, "token is not active")
+	}
+	delete(claims, introspectionActiveField)
+
+	// The provider has already checked these, but a response must not outlive the token
+	if err := jwt.NewValidator().Validate(claims); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "token has invalid claims: %v", err)
+	}
+	return claims, nil
+}
+
+func (a *IntrospectionAuthenticator) cached(key string) (jwt.MapClaims, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result, ok := a.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(result.expires) {
+		delete(a.cache, key)
+		return nil, false
+	}
+	return result.claims, true
+}
+
+// store caches active results until the token expires. Tokens without an expiry are not cached.
+func (a *IntrospectionAuthenticator) store(key string, claims jwt.MapClaims) {
+	if a.cacheSize <= 0 {
+		return
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return
+	}
+	expires := exp.Time
+	if a.maxCacheTTL > 0 && time.Until(expires) > a.maxCacheTTL {
+		expires = time.Now().Add(a.maxCacheTTL)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.cache) >= a.cacheSize {
+		now := time.Now()
+		for k, result := range a.cache {
+			if now.After(result.expires) {
+				delete(a.cache, k)
+			}
+		}
+		if len(a.cache) >= a.cacheSize {
+			return
+		}
+	}
+	a.cache[key] = introspectionResult{claims: claims, expires: expires}
+}
+
+// copyClaims deep copies the claims, including nested objects and arrays such as realm_access,
+// so that a caller modifying them does not change the cached result
+func copyClaims(claims jwt.MapClaims) jwt.MapClaims {
+	if claims == nil {
+		return nil
+	}
+	return jwt.MapClaims(copyClaimValue(map[string]interface{}(claims)).(map[string]interface{}))
+}
+
+// copyClaimValue deep copies the objects and arrays of a JSON value; other values are immutable
+func copyClaimValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case jwt.MapClaims:
+		return jwt.MapClaims(copyClaimValue(map[string]interface{}(v)).(map[string]interface{}))
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			c[k] = copyClaimValue(e)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = copyClaimValue(e)
+		}
+		return c
+	case []string:
+		return slices.Clone(v)
+	case map[string][]string:
+		c := make(map[string][]string, len(v))
+		for k, e := range v {
+			c[k] = slices.Clone(e)
+		}
+		return c
+	}
+	return value
+}
+
+// tokenCacheKey hashes the token so that the cache does not hold bearer tokens
+func tokenCacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testIntrospectionServer struct {
+	*httptest.Server
+	calls  atomic.Int32
+	tokens map[string]map[string]interface{}
+}
+
+func newTestIntrospectionServer(t *testing.T) *testIntrospectionServer {
+	s := &testIntrospectionServer{tokens: map[string]map[string]interface{}{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + OpenidConfiguration:
+			fmt.Fprintf(w, `{"issuer":"http://%s","introspection_endpoint":"http://%s/introspect"}`, r.Host, r.Host)
+		case "/introspect":
+			s.calls.Add(1)
+			clientID, clientSecret, ok := r.BasicAuth()
+			if !ok || clientID != "test-client" || clientSecret != "test-secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.NilError(t, r.ParseForm())
+			assert.Equal(t, "access_token", r.PostForm.Get("token_type_hint"))
+			response, ok := s.tokens[r.PostForm.Get("token")]
+			if !ok {
+				response = map[string]interface{}{"active": false}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			assert.NilError(t, json.NewEncoder(w).Encode(response))
+		default:
+			t.Fatalf("Unexpected URL %s", r.URL.String())
+		}
+	}))
+	return s
+}
+
+func TestIntrospectionAuthenticator(t *testing.T) {
+	server := newTestIntrospectionServer(t)
+	defer server.Close()
+	server.tokens["opaque-token"] = map[string]interface{}{
+		"active":   true,
+		"sub":      "test",
+		"username": "test-user",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+		"realm_access": map[string]interface{}{
+			"roles": []string{"role1", "role2"},
+		},
+	}
+	server.tokens["no-exp-token"] = map[string]interface{}{"active": true, "sub": "test"}
+	server.tokens["expired-token"] = map[string]interface{}{
+		"active": true,
+		"exp":    time.Now().Add(-time.Hour).Unix(),
+	}
+
+	authenticator, err := NewIntrospectionAuthenticator("test-client", "test-secret",
+		WithIntrospectionDiscoveryURL(server.URL))
+	assert.NilError(t, err)
+
+	claims, err := authenticator.Authenticate("opaque-token")
+	assert.NilError(t, err)
+	assert.Equal(t, "test-user", claims["username"])
+	_, hasActive := claims["active"]
+	assert.Assert(t, !hasActive)
+	subject, err := claims.GetSubject()
+	assert.NilError(t, err)
+	assert.Equal(t, "test", subject)
+
+	// The positive result is cached until the token expires, and callers get their own copy
+	claims["username"] = "changed"
+	roles := claims["realm_access"].(map[string]interface{})["roles"].([]interface{})
+	roles[0] = "admin"
+	claims["realm_access"].(map[string]interface{})["roles"] = append(roles, "admin")
+	claims, err = authenticator.Authenticate("opaque-token")
+	assert.NilError(t, err)
+	assert.Equal(t, int32(1), server.calls.Load())
+	assert.Equal(t, "test-user", claims["username"])
+	assert.DeepEqual(t, map[string]interface{}{"roles": []interface{}{"role1", "role2"}}, claims["realm_access"])
+
+	// Tokens without an expiry are not cached
+	_, err = authenticator.Authenticate("no-exp-token")
+	assert.NilError(t, err)
+	_, err = authenticator.Authenticate("no-exp-token")
+	assert.NilError(t, err)
+	assert.Equal(t, int32(3), server.calls.Load())
+
+	// Inactive tokens are rejected and not cached
+	_, err = authenticator.Authenticate("revoked-token")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.ErrorContains(t, err, "token is not active")
+	_, err = authenticator.Authenticate("expired-token")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.ErrorContains(t, err, "token is expired")
+}
+
+func TestIntrospectionAuthenticator_Errors(t *testing.T) {
+	server := newTestIntrospectionServer(t)
+	defer server.Close()
+
+	authenticator, err := NewIntrospectionAuthenticator("test-client", "wrong-secret",
+		WithIntrospectionEndpoint(server.URL+"/introspect"), WithIntrospectionCacheSize(0))
+	assert.NilError(t, err)
+	_, err = authenticator.Authenticate("opaque-token")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	_, err = NewIntrospectionAuthenticator("test-client", "test-secret")
+	assert.ErrorContains(t, err, "either an introspection endpoint or a discovery URL is required")
+
+	server.Close()
+	authenticator, err = NewIntrospectionAuthenticator("test-client", "test-secret",
+		WithIntrospectionEndpoint(server.URL+"/introspect"))
+	assert.NilError(t, err)
+	_, err = authenticator.Authenticate("opaque-token")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestIntrospectionAuthenticator_MaxCacheTTL(t *testing.T) {
+	server := newTestIntrospectionServer(t)
+	defer server.Close()
+	server.tokens["opaque-token"] = map[string]interface{}{
+		"active": true,
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+
+	authenticator, err := NewIntrospectionAuthenticator("test-client", "test-secret",
+		WithIntrospectionEndpoint(server.URL+"/introspect"), WithIntrospectionMaxCacheTTL(time.Millisecond))
+	assert.NilError(t, err)
+
+	_, err = authenticator.Authenticate("opaque-token")
+	assert.NilError(t, err)
+	time.Sleep(2 * time.Millisecond)
+
+	// A revocation is noticed once the cached result is older than the maximum TTL
+	delete(server.tokens, "opaque-token")
+	_, err = authenticator.Authenticate("opaque-token")
+	assert.ErrorContains(t, err, "token is not active")
+	assert.Equal(t, int32(2), server.calls.Load())
+}
@@ -0,0 +1,334 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-jose/go-jose/v3"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultJwksRefreshInterval is how often the key set is refreshed in the background
+	// when the JWKS response carries no Cache-Control max-age
+	DefaultJwksRefreshInterval = 1 * time.Hour
+	// DefaultJwksMinRefreshInterval is the minimum time between two fetches of the key set
+	// triggered by tokens carrying an unknown key ID
+	DefaultJwksMinRefreshInterval = 10 * time.Second
+)
+
+// ErrJwksKeyNotFound is returned when a key ID is not in the key set after it was fetched
+var ErrJwksKeyNotFound = errors.New("JWKS key ID not found")
+
+// ErrJwksRefreshRateLimited is returned when a key ID miss does not trigger a fetch
+// because the key set was fetched too recently
+var ErrJwksRefreshRateLimited = errors.New("JWKS refresh rate limited")
+
+type providerJSON struct {
+	Issuer           string   `json:"issuer"`
+	AuthURL          string   `json:"authorization_endpoint"`
+	TokenURL         string   `json:"token_endpoint"`
+	DeviceAuthURL    string   `json:"device_authorization_endpoint"`
+	JWKSURL          string   `json:"jwks_uri"`
+	UserInfoURL      string   `json:"userinfo_endpoint"`
+	IntrospectionURL string   `json:"introspection_endpoint"`
+	Algorithms       []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// fetchProviderConfig reads the OIDC discovery document below oidcURL
+func fetchProviderConfig(httpClient *http.Client, oidcURL string) (*providerJSON, error) {
+	resOpenIDConfig, err := httpClient.Get(fmt.Sprintf("%s/%s", oidcURL, OpenidConfiguration))
+	if err != nil {
+		return nil, fmt.Errorf("error obtaining information from OIDC well-known URL: %v", err)
+	}
+	if resOpenIDConfig.Body != nil {
+		defer resOpenIDConfig.Body.Close()
+	}
+	if resOpenIDConfig.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OIDC well-known URL returned %d", resOpenIDConfig.StatusCode)
+	}
+	openIDConfigBody, readErr := io.ReadAll(resOpenIDConfig.Body)
+	if readErr != nil {
+		return nil, fmt.Errorf("error reading Body of the OIDC configuration: %v", readErr)
+	}
+	var openIDprovider providerJSON
+	jsonErr := json.Unmarshal(openIDConfigBody, &openIDprovider)
+	if jsonErr != nil {
+		return nil, fmt.Errorf("error unmarshalling OIDC configuration: %v", jsonErr)
+	}
+	return &openIDprovider, nil
+}
+
+// JwksStatus reports the state of a JwksKeySet for health checks
+type JwksStatus struct {
+	// LastRefresh is the time of the last successful fetch of the key set
+	LastRefresh time.Time
+	// LastAttempt is the time of the last fetch, successful or not
+	LastAttempt time.Time
+	// LastError is the error of the last fetch, nil if it succeeded
+	LastError error
+	// NextRefresh is when the background refresh will next fetch the key set
+	NextRefresh time.Time
+	// Keys is the number of keys currently held
+	Keys int
+}
+
+// JwksKeySetOption configures a JwksKeySet
+type JwksKeySetOption func(*JwksKeySet)
+
+// WithJwksRefreshInterval sets the background refresh interval used when the
+// JWKS response carries no Cache-Control max-age
+func WithJwksRefreshInterval(interval time.Duration) JwksKeySetOption {
+	return func(k *JwksKeySet) {
+		k.refreshInterval = interval
+	}
+}
+
+// WithJwksMinRefreshInterval sets the minimum time between two fetches caused by unknown key IDs.
+// It is also the lower bound of the background refresh interval.
+func WithJwksMinRefreshInterval(interval time.Duration) JwksKeySetOption {
+	return func(k *JwksKeySet) {
+		k.minRefreshInterval = interval
+	}
+}
+
+// JwksKeySet holds the signing keys of one OIDC provider by key ID.
+// It is safe for concurrent use; concurrent fetches are collapsed into one.
+type JwksKeySet struct {
+	// discoveryURL is the base URL of the discovery document;
+	// if empty the OIDC_SERVER_URL environment variable is read on each fetch
+	discoveryURL       string
+	httpClient         *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	group              singleflight.Group
+
+	mu     sync.RWMutex
+	keys   map[string]jose.JSONWebKey
+	maxAge time.Duration
+	status JwksStatus
+}
+
+// NewJwksKeySet creates a key set fetched through the discovery document at discoveryURL
+func NewJwksKeySet(discoveryURL string, httpClient *http.Client, opts ...JwksKeySetOption) *JwksKeySet {
+	k := &JwksKeySet{
+		discoveryURL:       strings.TrimSuffix(discoveryURL, "/"),
+		httpClient:         httpClient,
+		refreshInterval:    DefaultJwksRefreshInterval,
+		minRefreshInterval: DefaultJwksMinRefreshInterval,
+		keys:               make(map[string]jose.JSONWebKey),
+	}
+	if k.httpClient == nil {
+		k.httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	return k
+}
+
+// Lookup returns the key with the given key ID
+func (k *JwksKeySet) Lookup(keyID string) (jose.JSONWebKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[keyID]
+	return key, ok
+}
+
+// LookupOrRefresh returns the key with the given key ID, fetching the key set once if the
+// key is unknown. Fetches caused by unknown key IDs are rate limited, so a flood of tokens
+// with forged key IDs cannot turn into a flood of requests to the OIDC provider.
+func (k *JwksKeySet) LookupOrRefresh(keyID string) (jose.JSONWebKey, error) {
+	if key, ok := k.Lookup(keyID); ok {
+		return key, nil
+	}
+	_, err, _ := k.group.Do("miss", func() (interface{}, error) {
+		k.mu.RLock()
+		lastAttempt := k.status.LastAttempt
+		k.mu.RUnlock()
+		if !lastAttempt.IsZero() && time.Since(lastAttempt) < k.minRefreshInterval {
+			return nil, ErrJwksRefreshRateLimited
+		}
+		return nil, k.Refresh()
+	})
+	// The key may have been fetched by a concurrent caller even if this one was rate limited
+	if key, ok := k.Lookup(keyID); ok {
+		return key, nil
+	}
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+	return jose.JSONWebKey{}, ErrJwksKeyNotFound
+}
+
+// Refresh fetches the key set now. Concurrent callers share a single fetch.
+func (k *JwksKeySet) Refresh() error {
+	_, err, _ := k.group.Do("refresh", func() (interface{}, error) {
+		return nil, k.fetch()
+	})
+	return err
+}
+
+// Start refreshes the key set in the background until the context is done.
+// The interval honors the Cache-Control max-age of the JWKS response.
+func (k *JwksKeySet) Start(ctx context.Context) {
+	go func() {
+		if err := k.Refresh(); err != nil {
+			log.Warnf("unable to refresh JWKS keys %s", err)
+		}
+		k.refreshLoop(ctx)
+	}()
+}
+
+// refreshLoop refreshes the key set each refresh interval until the context is done
+func (k *JwksKeySet) refreshLoop(ctx context.Context) {
+	for {
+		interval := k.nextInterval()
+		k.mu.Lock()
+		k.status.NextRefresh = time.Now().Add(interval)
+		k.mu.Unlock()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := k.Refresh(); err != nil {
+				log.Warnf("unable to refresh JWKS keys %s", err)
+			}
+		}
+	}
+}
+
+// Status returns the refresh status of the key set
+func (k *JwksKeySet) Status() JwksStatus {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	status := k.status
+	status.Keys = len(k.keys)
+	return status
+}
+
+func (k *JwksKeySet) nextInterval() time.Duration {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	interval := k.refreshInterval
+	if k.maxAge > 0 {
+		interval = k.maxAge
+	}
+	if k.status.LastError != nil || interval < k.minRefreshInterval {
+		interval = k.minRefreshInterval
+	}
+	return interval
+}
+
+func (k *JwksKeySet) fetch() error {
+	k.mu.Lock()
+	k.status.LastAttempt = time.Now()
+	k.mu.Unlock()
+
+	keys, maxAge, err := k.fetchKeys()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.status.LastError = err
+	if err != nil {
+		return err
+	}
+	k.keys = keys
+	k.maxAge = maxAge
+	k.status.LastRefresh = time.Now()
+	return nil
+}
+
+// Connect back to the OpenIDConnect server to retrieve the keys
+// They are rotated every 6 hours by default - we keep the keys in a cache
+// It's a 2 step process
+// 1) connect to $OIDCServerURL/.well-known/openid-configuration and retrieve the JSON payload
+// 2) lookup the "keys" parameter and get keys from $OIDCServerURL/keys
+// The keys are kept by key ID and resolved by their JWK type (RSA, EC or OKP)
+func (k *JwksKeySet) fetchKeys() (map[string]jose.JSONWebKey, time.Duration, error) {
+	oidcURL := k.discoveryURL
+	if oidcURL == "" {
+		var present bool
+		oidcURL, present = os.LookupEnv(OIDCServerURL)
+		if !present {
+			return nil, 0, fmt.Errorf("environmental variable OIDC_SERVER_URL is not set " +
+				"Can't reach the OIDC server to refresh JWKS")
+		}
+	}
+
+	openIDprovider, err := fetchProviderConfig(k.httpClient, oidcURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	resOpenIDKeys, err := k.httpClient.Get(openIDprovider.JWKSURL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error retrieving JWKS from the OIDC provider: %v", err)
+	}
+	if resOpenIDKeys.Body != nil {
+		defer resOpenIDKeys.Body.Close()
+	}
+	if resOpenIDKeys.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("JWKS URL returned %d", resOpenIDKeys.StatusCode)
+	}
+	bodyOpenIDKeys, readErr := io.ReadAll(resOpenIDKeys.Body)
+	if readErr != nil {
+		return nil, 0, fmt.Errorf("error reading keys from the Body of the response: %v", readErr)
+	}
+	var jsonWebKeySet jose.JSONWebKeySet
+	if err := json.Unmarshal(bodyOpenIDKeys, &jsonWebKeySet); err != nil {
+		return nil, 0, fmt.Errorf("error unmarshalling JWKS to JSON: %v", err)
+	}
+
+	keys := make(map[string]jose.JSONWebKey)
+	for _, key := range jsonWebKeySet.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if !key.Valid() || !key.IsPublic() {
+			log.Warnf("ignoring invalid JWKS key %s", key.KeyID)
+			continue
+		}
+		switch key.Key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+			keys[key.KeyID] = key
+		default:
+			log.Warnf("ignoring JWKS key %s of unsupported type %T", key.KeyID, key.Key)
+		}
+	}
+	log.Infof("Refreshed JWKS keys from %s", openIDprovider.JWKSURL)
+
+	return keys, cacheMaxAge(resOpenIDKeys.Header.Get("Cache-Control")), nil
+}
+
+// cacheMaxAge returns the max-age of a Cache-Control header, or 0 if there is none
+func cacheMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err != nil || seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
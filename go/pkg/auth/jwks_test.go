@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-jose/go-jose/v3"
+	"gotest.tools/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testJwksServer struct {
+	*httptest.Server
+	mu           sync.Mutex
+	keys         []jose.JSONWebKey
+	cacheControl string
+	jwksFetches  atomic.Int32
+}
+
+func newTestJwksServer(t *testing.T, keys ...jose.JSONWebKey) *testJwksServer {
+	s := &testJwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.String() {
+		case "/" + OpenidConfiguration:
+			fmt.Fprintln(w, strings.ReplaceAll(dexWellKnownOpenIDConfig, "http://dex:32000", "http://"+r.Host))
+		case "/keys":
+			s.jwksFetches.Add(1)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.cacheControl != "" {
+				w.Header().Set("Cache-Control", s.cacheControl)
+			}
+			// Slow down the response so that concurrent fetches overlap
+			time.Sleep(10 * time.Millisecond)
+			assert.NilError(t, json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: s.keys}))
+		default:
+			t.Fatalf("Unexpected URL %s", r.URL.String())
+		}
+	}))
+	return s
+}
+
+func (s *testJwksServer) setKeys(keys ...jose.JSONWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func newTestJwk(t *testing.T, kid string) jose.JSONWebKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	return jose.JSONWebKey{Key: &key.PublicKey, KeyID: kid, Use: "sig"}
+}
+
+func TestJwksKeySet_ConcurrentKeyIDMisses(t *testing.T) {
+	server := newTestJwksServer(t, newTestJwk(t, "kid1"))
+	defer server.Close()
+
+	keySet := NewJwksKeySet(server.URL, server.Client(), WithJwksMinRefreshInterval(time.Hour))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = keySet.LookupOrRefresh(fmt.Sprintf("forged-%d", i))
+			_, _ = keySet.LookupOrRefresh("kid1")
+		}(i)
+	}
+	wg.Wait()
+
+	// All the misses are served by a single fetch
+	assert.Equal(t, int32(1), server.jwksFetches.Load())
+	_, ok := keySet.Lookup("kid1")
+	assert.Assert(t, ok)
+
+	// Further misses within the minimum refresh interval do not reach the server
+	_, err := keySet.LookupOrRefresh("forged")
+	assert.Assert(t, errors.Is(err, ErrJwksRefreshRateLimited))
+	assert.Equal(t, int32(1), server.jwksFetches.Load())
+}
+
+func TestJwksKeySet_KeyRotation(t *testing.T) {
+	server := newTestJwksServer(t, newTestJwk(t, "kid1"))
+	defer server.Close()
+
+	keySet := NewJwksKeySet(server.URL, server.Client(), WithJwksMinRefreshInterval(time.Millisecond))
+
+	_, err := keySet.LookupOrRefresh("kid1")
+	assert.NilError(t, err)
+
+	server.setKeys(newTestJwk(t, "kid2"))
+	time.Sleep(2 * time.Millisecond)
+	_, err = keySet.LookupOrRefresh("kid2")
+	assert.NilError(t, err)
+	_, ok := keySet.Lookup("kid1")
+	assert.Assert(t, !ok, "rotated key should be removed")
+
+	time.Sleep(2 * time.Millisecond)
+	_, err = keySet.LookupOrRefresh("kid3")
+	assert.Assert(t, errors.Is(err, ErrJwksKeyNotFound))
+}
+
+func TestJwksKeySet_BackgroundRefresh(t *testing.T) {
+	server := newTestJwksServer(t, newTestJwk(t, "kid1"))
+	defer server.Close()
+	server.cacheControl = "public, max-age=3600"
+
+	keySet := NewJwksKeySet(server.URL, server.Client(),
+		WithJwksRefreshInterval(20*time.Millisecond), WithJwksMinRefreshInterval(10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keySet.Start(ctx)
+
+	assert.Assert(t, waitFor(func() bool { return keySet.Status().Keys == 1 }))
+	status := keySet.Status()
+	assert.NilError(t, status.LastError)
+	assert.Assert(t, !status.LastRefresh.IsZero())
+	// The Cache-Control max-age takes precedence over the refresh interval
+	assert.Assert(t, waitFor(func() bool { return time.Until(keySet.Status().NextRefresh) > 50*time.Minute }))
+	assert.Equal(t, int32(1), server.jwksFetches.Load())
+}
+
+func TestJwksKeySet_Status(t *testing.T) {
+	server := newTestJwksServer(t)
+	server.Close()
+
+	keySet := NewJwksKeySet(server.URL, nil)
+	err := keySet.Refresh()
+	assert.Assert(t, err != nil)
+	status := keySet.Status()
+	assert.Assert(t, status.LastError != nil)
+	assert.Assert(t, status.LastRefresh.IsZero())
+	assert.Assert(t, !status.LastAttempt.IsZero())
+	assert.Equal(t, 0, status.Keys)
+}
+
+func Test_cacheMaxAge(t *testing.T) {
+	assert.Equal(t, time.Duration(0), cacheMaxAge(""))
+	assert.Equal(t, time.Duration(0), cacheMaxAge("no-cache"))
+	assert.Equal(t, 300*time.Second, cacheMaxAge("public, max-age=300"))
+	assert.Equal(t, 60*time.Second, cacheMaxAge(`Max-Age="60", must-revalidate`))
+	assert.Equal(t, time.Duration(0), cacheMaxAge("max-age=abc"))
+}
+
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
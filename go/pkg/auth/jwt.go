@@ -4,19 +4,20 @@
 package auth
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/tls"
-	"crypto/x509"
-	"encoding/json"
-	"encoding/pem"
-	"fmt"
-	"github.com/go-jose/go-jose/v3"
+	"errors"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/open-edge-platform/orch-library/go/dazl"
-
-	"io"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc/status"
 
@@ -25,8 +26,6 @@ import (
 
 var log = dazl.GetLogger()
 
-var publicKeys map[string][]byte
-
 const (
 	// SharedSecretKey shared secret key for signing a token
 	SharedSecretKey = "SHARED_SECRET_KEY"
@@ -44,160 +43,366 @@ const (
 	RS = "RS"
 	// PS prefix for PS family algorithms
 	PS = "PS"
+	// ES prefix for ES family algorithms
+	ES = "ES"
+	// EdDSA the EdDSA algorithm
+	EdDSA = "EdDSA"
+
+	// authorizedPartyClaim the OIDC "azp" claim naming the client the token was issued to
+	authorizedPartyClaim = "azp"
 )
 
-type providerJSON struct {
-	Issuer        string   `json:"issuer"`
-	AuthURL       string   `json:"authorization_endpoint"`
-	TokenURL      string   `json:"token_endpoint"`
-	DeviceAuthURL string   `json:"device_authorization_endpoint"`
-	JWKSURL       string   `json:"jwks_uri"`
-	UserInfoURL   string   `json:"userinfo_endpoint"`
-	Algorithms    []string `json:"id_token_signing_alg_values_supported"`
+// defaultAllowedAlgorithms the signing algorithms accepted when a JwtAuthenticator has no explicit allow-list
+var defaultAllowedAlgorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	EdDSA,
 }
 
-func init() {
-	publicKeys = make(map[string][]byte)
-	if err := refreshJwksKeys(); err != nil {
-		log.Debugf("unable to refresh JWKS keys on init %s", err)
-	}
+// DefaultAllowedAlgorithms returns the signing algorithms accepted when a JwtAuthenticator has no
+// explicit allow-list. The slice is a copy, so changing it does not change the default.
+func DefaultAllowedAlgorithms() []string {
+	return slices.Clone(defaultAllowedAlgorithms)
 }
 
-// JwtAuthenticator jwt authenticator
-type JwtAuthenticator struct {
-}
+var (
+	// envKeys is the key cache shared by JwtAuthenticators that are not created with
+	// NewJwtAuthenticator; it is configured from the OIDC_SERVER_URL environment variable
+	// and refreshed in the background for the lifetime of the process
+	envKeys     *JwksKeySet
+	envKeysOnce sync.Once
+)
 
-// ParseToken parse token and Ensure that the JWT conforms to the structure of a JWT.
-func (j *JwtAuthenticator) parseToken(tokenString string) (*jwt.Token, jwt.Claims, error) {
-	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// HS256, HS384, or HS512
-		if strings.HasPrefix(token.Method.Alg(), HS) {
-			key := os.Getenv(SharedSecretKey)
-			return []byte(key), nil
-			// ES256, ES384, ES512, PS256, PS384, PS512, RS256, RS384, RS512
-		} else if strings.HasPrefix(token.Method.Alg(), RS) || strings.HasPrefix(token.Method.Alg(), PS) {
-			keyID, ok := token.Header["kid"]
-			if !ok {
-				return nil, status.Errorf(codes.Unauthenticated, "token header not found 'kid' (key ID)")
-			}
-			keyIDStr := keyID.(string)
-			publicKey, ok := publicKeys[keyIDStr]
-			if !ok {
-				// Keys may have been refreshed on the server
-				// Fetch them again and try once more before failing
-				if err := refreshJwksKeys(); err != nil {
-					return nil, status.Errorf(codes.Unauthenticated, "unable to refresh keys from ID provider %s", err)
-				}
-				// try again after refresh
-				if publicKey, ok = publicKeys[keyIDStr]; !ok {
-					return nil, status.Errorf(codes.Unauthenticated, "token has obsolete key ID %s", keyID)
-				}
-			}
-			rsaPublicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKey)
-			if err != nil {
-				return nil, status.Error(codes.Unauthenticated, err.Error())
-			}
-			return rsaPublicKey, nil
+func getEnvKeys() *JwksKeySet {
+	envKeysOnce.Do(func() {
+		envKeys = NewJwksKeySet("", envHTTPClient())
+		// The first fetch is done before any lookup, so that the lookup is not rate limited
+		// by a fetch still running in the background
+		if err := envKeys.Refresh(); err != nil {
+			log.Warnf("unable to refresh JWKS keys %s", err)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "unknown signing algorithm: %s", token.Method.Alg())
+		go envKeys.refreshLoop(context.Background())
 	})
+	return envKeys
+}
 
-	return token, claims, err
+func envHTTPClient() *http.Client {
+	oidcClient := new(http.Client)
 
+	oidcTLSInsecureSkipVerify := os.Getenv(OIDCTlsInsecureSkipVerify)
+	if strings.ToLower(oidcTLSInsecureSkipVerify) == "true" {
+		oidcClient.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: false,
+				MinVersion:         tls.VersionTLS12,
+			},
+		}
+	}
+	return oidcClient
 }
 
-// ParseAndValidate parse a jwt string token and validate it
-func (j *JwtAuthenticator) ParseAndValidate(tokenString string) (jwt.Claims, error) {
-	token, claims, err := j.parseToken(tokenString)
-	if err != nil {
-		log.Warnf("cannot parse token. %s", err.Error())
-		return nil, err
+// JwtAuthenticatorOption configures a JwtAuthenticator
+type JwtAuthenticatorOption func(*JwtAuthenticator)
+
+// WithIssuer sets the expected "iss" claim. Unless WithDiscoveryURL is given, the issuer URL
+// is also used to discover the JWKS endpoint.
+func WithIssuer(issuer string) JwtAuthenticatorOption {
+	return func(j *JwtAuthenticator) {
+		j.issuer = issuer
 	}
+}
 
-	// Check the token is valid
-	if !token.Valid {
-		return nil, status.Errorf(codes.Unauthenticated, "token is not valid %v", token)
+// WithDiscoveryURL sets the base URL of the OIDC discovery document when it differs from
+// the issuer, e.g. when the IdP is reached through an in-cluster address
+func WithDiscoveryURL(discoveryURL string) JwtAuthenticatorOption {
+	return func(j *JwtAuthenticator) {
+		j.discoveryURL = strings.TrimSuffix(discoveryURL, "/")
 	}
+}
 
-	return claims, nil
+// WithAudiences sets the accepted audiences; a token must carry at least one of them in its "aud" claim
+func WithAudiences(audiences ...string) JwtAuthenticatorOption {
+	return func(j *JwtAuthenticator) {
+		j.audiences = audiences
+	}
 }
 
-// Connect back to the OpenIDConnect server to retrieve the keys
-// They are rotated every 6 hours by default - we keep the keys in a cache
-// It's a 2 step process
-// 1) connect to $OIDCServerURL/.well-known/openid-configuration and retrieve the JSON payload
-// 2) lookup the "keys" parameter and get keys from $OIDCServerURL/keys
-// The keys are in a public key format and are converted to RSA Public Keys
-func refreshJwksKeys() error {
-	oidcURL, present := os.LookupEnv(OIDCServerURL)
-	if !present {
-		return fmt.Errorf("environmental variable OIDC_SERVER_URL is not set " +
-			"Can't reach the OIDC server to refresh JWKS")
+// WithAuthorizedParties sets the accepted values of the "azp" claim
+func WithAuthorizedParties(parties ...string) JwtAuthenticatorOption {
+	return func(j *JwtAuthenticator) {
+		j.authorizedParties = parties
 	}
+}
 
-	oidcClient := new(http.Client)
+// WithAllowedAlgorithms sets the signing algorithms accepted in the token "alg" header
+func WithAllowedAlgorithms(algorithms ...string) JwtAuthenticatorOption {
+	return func(j *JwtAuthenticator) {
+		j.AllowedAlgorithms = algorithms
+	}
+}
 
-	oidcTLSInsecureSkipVerify := os.Getenv(OIDCTlsInsecureSkipVerify)
-	if strings.ToLower(oidcTLSInsecureSkipVerify) == "true" {
-		oidcClient.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: false,
-				MinVersion:         tls.VersionTLS12,
-			},
-		}
+// WithClockSkew sets the leeway allowed when validating the "exp", "nbf" and "iat" claims
+func WithClockSkew(skew time.Duration) JwtAuthenticatorOption {
+	return func(j *JwtAuthenticator) {
+		j.clockSkew = skew
 	}
+}
 
-	resOpenIDConfig, err := oidcClient.Get(fmt.Sprintf("%s/%s", oidcURL, OpenidConfiguration))
-	if err != nil {
-		return fmt.Errorf("error obtaining information from OIDC well-known URL: %v", err)
+// WithHTTPClient sets the HTTP client used to reach the OIDC server
+func WithHTTPClient(client *http.Client) JwtAuthenticatorOption {
+	return func(j *JwtAuthenticator) {
+		j.httpClient = client
 	}
-	if resOpenIDConfig.Body != nil {
-		defer resOpenIDConfig.Body.Close()
+}
+
+// WithKeySet sets the key set used to verify signatures, e.g. to share one key set between
+// authenticators or to run its background refresh. By default each authenticator has its own key set.
+func WithKeySet(keySet *JwksKeySet) JwtAuthenticatorOption {
+	return func(j *JwtAuthenticator) {
+		j.keys = keySet
 	}
-	openIDConfigBody, readErr := io.ReadAll(resOpenIDConfig.Body)
-	if readErr != nil {
-		return fmt.Errorf("error reading Body of the OIDC configuration: %v", readErr)
+}
+
+// WithSharedSecret sets the secret used to verify HS256, HS384 and HS512 signed tokens.
+// Without it an authenticator created with NewJwtAuthenticator does not accept HS signed tokens.
+func WithSharedSecret(secret []byte) JwtAuthenticatorOption {
+	return func(j *JwtAuthenticator) {
+		j.sharedSecret = secret
 	}
-	var openIDprovider providerJSON
-	jsonErr := json.Unmarshal(openIDConfigBody, &openIDprovider)
-	if jsonErr != nil {
-		return fmt.Errorf("error unmarshalling OIDC configuration: %v", jsonErr)
+}
+
+// JwtAuthenticator jwt authenticator.
+// A zero value JwtAuthenticator is configured from the OIDC_SERVER_URL and SHARED_SECRET_KEY
+// environment variables and shares its key cache with other zero value authenticators;
+// use NewJwtAuthenticator for an authenticator with its own configuration and key cache.
+type JwtAuthenticator struct {
+	// AllowedAlgorithms the signing algorithms accepted in the token "alg" header.
+	// If empty, DefaultAllowedAlgorithms() is used, without the HS algorithms for an authenticator
+	// created with NewJwtAuthenticator and no shared secret.
+	AllowedAlgorithms []string
+
+	issuer            string
+	discoveryURL      string
+	audiences         []string
+	authorizedParties []string
+	clockSkew         time.Duration
+	httpClient        *http.Client
+	sharedSecret      []byte
+	keys              *JwksKeySet
+}
+
+var _ Authenticator = &JwtAuthenticator{}
+
+// NewJwtAuthenticator creates a JwtAuthenticator with its own key cache. It ignores the
+// SHARED_SECRET_KEY environment variable, see WithSharedSecret.
+func NewJwtAuthenticator(opts ...JwtAuthenticatorOption) *JwtAuthenticator {
+	j := &JwtAuthenticator{}
+	for _, opt := range opts {
+		opt(j)
 	}
-	resOpenIDKeys, err := oidcClient.Get(openIDprovider.JWKSURL)
-	if err != nil {
-		return fmt.Errorf("error retrieving JWKS from the OIDC provider: %v", err)
+	if j.httpClient == nil {
+		j.httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if j.keys == nil {
+		discoveryURL := j.discoveryURL
+		if discoveryURL == "" {
+			discoveryURL = j.issuer
+		}
+		j.keys = NewJwksKeySet(discoveryURL, j.httpClient)
 	}
-	if resOpenIDKeys.Body != nil {
-		defer resOpenIDKeys.Body.Close()
+	return j
+}
+
+// Issuer returns the expected issuer, if any
+func (j *JwtAuthenticator) Issuer() string {
+	return j.issuer
+}
+
+// fromEnv returns true for a zero value authenticator, which is configured from the environment
+func (j *JwtAuthenticator) fromEnv() bool {
+	return j.keys == nil
+}
+
+func (j *JwtAuthenticator) allowedAlgorithms() []string {
+	if len(j.AllowedAlgorithms) > 0 {
+		return j.AllowedAlgorithms
 	}
-	bodyOpenIDKeys, readErr := io.ReadAll(resOpenIDKeys.Body)
-	if readErr != nil {
-		return fmt.Errorf("error reading keys from the Body of the response: %v", readErr)
+	if j.fromEnv() || len(j.sharedSecret) > 0 {
+		return DefaultAllowedAlgorithms()
 	}
-	var jsonWebKeySet jose.JSONWebKeySet
-	if err := json.Unmarshal(bodyOpenIDKeys, &jsonWebKeySet); err != nil {
-		return fmt.Errorf("error unmarshalling JWKS to JSON: %v", err)
+	// Without a shared secret an authenticator only accepts tokens signed by its issuer
+	return slices.DeleteFunc(DefaultAllowedAlgorithms(), func(alg string) bool {
+		return strings.HasPrefix(alg, HS)
+	})
+}
+
+// KeySet returns the key set used to verify signatures
+func (j *JwtAuthenticator) KeySet() *JwksKeySet {
+	if j.keys == nil {
+		return getEnvKeys()
 	}
+	return j.keys
+}
 
-	// Clear out old keys
-	for k := range publicKeys {
-		delete(publicKeys, k)
+func (j *JwtAuthenticator) parserOptions() []jwt.ParserOption {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(j.allowedAlgorithms()),
+		jwt.WithLeeway(j.clockSkew),
 	}
+	if j.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(j.issuer))
+	}
+	return opts
+}
+
+// ParseToken parse token and Ensure that the JWT conforms to the structure of a JWT.
+func (j *JwtAuthenticator) parseToken(tokenString string) (*jwt.Token, jwt.Claims, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(j.parserOptions()...)
+	token, err := parser.ParseWithClaims(tokenString, claims, j.keyFunc)
+
+	return token, claims, err
+
+}
 
-	for _, key := range jsonWebKeySet.Keys {
-		data, err := x509.MarshalPKIXPublicKey(key.Key)
+// keyFunc resolves the key used to verify the token signature. The key type
+// must match the signing method so that a token cannot select a weaker check.
+func (j *JwtAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	// HS256, HS384, or HS512
+	case *jwt.SigningMethodHMAC:
+		key := j.sharedSecret
+		if j.fromEnv() {
+			key = []byte(os.Getenv(SharedSecretKey))
+		}
+		if len(key) == 0 {
+			return nil, status.Errorf(codes.Unauthenticated, "no shared secret configured for signing algorithm %s", token.Method.Alg())
+		}
+		return key, nil
+	// RS256, RS384, RS512, PS256, PS384, PS512
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key, err := j.lookupPublicKey(token)
+		if err != nil {
+			return nil, err
+		}
+		if rsaKey, ok := key.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+	// ES256, ES384, ES512
+	case *jwt.SigningMethodECDSA:
+		key, err := j.lookupPublicKey(token)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		block := pem.Block{
-			Type:  "PUBLIC KEY",
-			Bytes: data,
+		if ecKey, ok := key.(*ecdsa.PublicKey); ok {
+			return ecKey, nil
 		}
-		pemBytes := pem.EncodeToMemory(&block)
-		publicKeys[key.KeyID] = pemBytes
+	// EdDSA (Ed25519)
+	case *jwt.SigningMethodEd25519:
+		key, err := j.lookupPublicKey(token)
+		if err != nil {
+			return nil, err
+		}
+		if edKey, ok := key.(ed25519.PublicKey); ok {
+			return edKey, nil
+		}
+	default:
+		return nil, status.Errorf(codes.Unauthenticated, "unknown signing algorithm: %s", token.Method.Alg())
 	}
-	log.Infof("Refreshed JWKS keys from %s", openIDprovider.JWKSURL)
+	return nil, status.Errorf(codes.Unauthenticated, "key ID %v does not match signing algorithm %s",
+		token.Header["kid"], token.Method.Alg())
+}
+
+// lookupPublicKey finds the JWKS key referenced by the token "kid" header
+func (j *JwtAuthenticator) lookupPublicKey(token *jwt.Token) (interface{}, error) {
+	keyID, ok := token.Header["kid"]
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "token header not found 'kid' (key ID)")
+	}
+	keyIDStr, ok := keyID.(string)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "token header 'kid' (key ID) is not a string")
+	}
+	// Keys may have been refreshed on the server, an unknown key ID
+	// makes the key set fetch them again before failing
+	jwk, err := j.KeySet().LookupOrRefresh(keyIDStr)
+	if errors.Is(err, ErrJwksKeyNotFound) || errors.Is(err, ErrJwksRefreshRateLimited) {
+		return nil, status.Errorf(codes.Unauthenticated, "token has obsolete key ID %s", keyID)
+	} else if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "unable to refresh keys from ID provider %s", err)
+	}
+	// A key published with an "alg" may only be used with that algorithm
+	if jwk.Algorithm != "" && jwk.Algorithm != token.Method.Alg() {
+		return nil, status.Errorf(codes.Unauthenticated, "key ID %s is not valid for signing algorithm %s",
+			keyIDStr, token.Method.Alg())
+	}
+	return jwk.Key, nil
+}
 
+// validateClaims checks the claims that the JWT parser does not validate on its own
+func (j *JwtAuthenticator) validateClaims(claims jwt.MapClaims) error {
+	if len(j.audiences) > 0 {
+		audiences, err := claims.GetAudience()
+		if err != nil {
+			return status.Errorf(codes.Unauthenticated, "token has invalid audience: %v", err)
+		}
+		if !slices.ContainsFunc(audiences, func(aud string) bool {
+			return slices.Contains(j.audiences, aud)
+		}) {
+			return status.Errorf(codes.Unauthenticated, "token audience %v is not accepted", audiences)
+		}
+	}
+	if len(j.authorizedParties) > 0 {
+		azp, _ := claims[authorizedPartyClaim].(string)
+		if !slices.Contains(j.authorizedParties, azp) {
+			return status.Errorf(codes.Unauthenticated, "token authorized party %q is not accepted", azp)
+		}
+	}
 	return nil
 }
+
+// ParseAndValidate parse a jwt string token and validate it
+func (j *JwtAuthenticator) ParseAndValidate(tokenString string) (jwt.Claims, error) {
+	token, claims, err := j.parseToken(tokenString)
+	if err != nil {
+		log.Warnf("cannot parse token. %s", err.Error())
+		return nil, err
+	}
+
+	// Check the token is valid
+	if !token.Valid {
+		return nil, status.Errorf(codes.Unauthenticated, "token is not valid %v", token)
+	}
+
+	if err := j.validateClaims(claims.(jwt.MapClaims)); err != nil {
+		log.Warnf("token claims rejected. %s", err.Error())
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// Authenticate implements Authenticator by parsing and validating a jwt string token
+func (j *JwtAuthenticator) Authenticate(tokenString string) (jwt.MapClaims, error) {
+	claims, err := j.ParseAndValidate(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return claims.(jwt.MapClaims), nil
+}
+
+// UnverifiedExpiry returns the "exp" claim of a JWT without verifying the token, or zero if it has none.
+// It is meant for tokens obtained by the caller, to know when to replace them, not to authenticate callers.
+func UnverifiedExpiry(accessToken string) time.Time {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(accessToken, claims); err != nil {
+		return time.Time{}
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}
+	}
+	return exp.Time
+}
@@ -4,7 +4,13 @@
 package auth
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
 	"fmt"
+	"github.com/go-jose/go-jose/v3"
 	"github.com/golang-jwt/jwt/v5"
 	"gotest.tools/assert"
 	"net/http"
@@ -12,6 +18,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 const dexWellKnownOpenIDConfig = `{
@@ -138,6 +145,14 @@ func TestJwtAuthenticator_parseToken(t *testing.T) {
 
 }
 
+func TestJwtAuthenticator_EnvKeysBackgroundRefresh(t *testing.T) {
+	// The key set of zero value authenticators is refreshed in the background once it is used
+	keySet := new(JwtAuthenticator).KeySet()
+	assert.Equal(t, keySet, getEnvKeys())
+	assert.Assert(t, !keySet.Status().LastAttempt.IsZero())
+	assert.Assert(t, waitFor(func() bool { return !keySet.Status().NextRefresh.IsZero() }))
+}
+
 func TestJwtAuthenticator_HSAlgorithm(t *testing.T) {
 	authenticator := new(JwtAuthenticator)
 
@@ -174,3 +189,230 @@ func TestJwtAuthenticator_HSAlgorithm(t *testing.T) {
 	assert.Equal(t, "sean@opennetworking.org", email, "error unexpected email", email)
 
 }
+
+// startJwksServer serves a discovery document and a JWKS containing the given keys
+func startJwksServer(t *testing.T, keys ...jose.JSONWebKey) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.String() {
+		case "/" + OpenidConfiguration:
+			fmt.Fprintln(w, strings.ReplaceAll(dexWellKnownOpenIDConfig, "http://dex:32000", "http://"+r.Host))
+		case "/keys":
+			assert.NilError(t, json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: keys}))
+		default:
+			t.Fatalf("Unexpected URL %s", r.URL.String())
+		}
+	}))
+	return ts
+}
+
+func signTestToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	now := time.Now()
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"iss":   "http://dex:32000",
+		"sub":   "test",
+		"email": "test1@opennetworking.org",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	assert.NilError(t, err)
+	return s
+}
+
+func TestJwtAuthenticator_ESAndEdDSAAlgorithms(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	ts := startJwksServer(t,
+		jose.JSONWebKey{Key: &ecKey.PublicKey, KeyID: "ec-key", Algorithm: "ES256", Use: "sig"},
+		jose.JSONWebKey{Key: edPublic, KeyID: "ed-key", Algorithm: EdDSA, Use: "sig"},
+	)
+	defer ts.Close()
+
+	authenticator := NewJwtAuthenticator(WithDiscoveryURL(ts.URL))
+
+	claims, err := authenticator.ParseAndValidate(signTestToken(t, jwt.SigningMethodES256, "ec-key", ecKey))
+	assert.NilError(t, err)
+	assert.Equal(t, "test1@opennetworking.org", claims.(jwt.MapClaims)["email"])
+
+	claims, err = authenticator.ParseAndValidate(signTestToken(t, jwt.SigningMethodEdDSA, "ed-key", edPrivate))
+	assert.NilError(t, err)
+	assert.Equal(t, "test1@opennetworking.org", claims.(jwt.MapClaims)["email"])
+
+	// A token cannot use a key with a different signing algorithm than the one it is published for
+	_, err = authenticator.ParseAndValidate(signTestToken(t, jwt.SigningMethodES256, "ed-key", ecKey))
+	assert.ErrorContains(t, err, "not valid for signing algorithm ES256")
+}
+
+func TestJwtAuthenticator_AllowedAlgorithms(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	ts := startJwksServer(t, jose.JSONWebKey{Key: &ecKey.PublicKey, KeyID: "ec-key-2", Use: "sig"})
+	defer ts.Close()
+
+	authenticator := NewJwtAuthenticator(
+		WithDiscoveryURL(ts.URL),
+		WithSharedSecret([]byte(sharedSecretKey)),
+		WithAllowedAlgorithms("ES256"),
+	)
+
+	_, err = authenticator.ParseAndValidate(signTestToken(t, jwt.SigningMethodES256, "ec-key-2", ecKey))
+	assert.NilError(t, err)
+
+	// HS256 is not in the allow-list so the shared secret must not be used to verify it
+	_, err = authenticator.ParseAndValidate(sampleTokenHS256Signature)
+	assert.ErrorContains(t, err, "signing method HS256 is invalid")
+}
+
+func TestNewJwtAuthenticator_ClaimValidation(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	ts := startJwksServer(t, jose.JSONWebKey{Key: &ecKey.PublicKey, KeyID: "ec-key-3", Algorithm: "ES256", Use: "sig"})
+	defer ts.Close()
+
+	authenticator := NewJwtAuthenticator(
+		WithIssuer(ts.URL),
+		WithAudiences("catalog", "app-deployment-manager"),
+		WithAuthorizedParties("webui-client"),
+		WithAllowedAlgorithms("ES256"),
+		WithClockSkew(time.Minute),
+		WithHTTPClient(ts.Client()),
+	)
+
+	sign := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = "ec-key-3"
+		s, err := token.SignedString(ecKey)
+		assert.NilError(t, err)
+		return s
+	}
+	validClaims := func() jwt.MapClaims {
+		now := time.Now()
+		return jwt.MapClaims{
+			"iss": ts.URL,
+			"aud": []string{"account", "catalog"},
+			"azp": "webui-client",
+			"sub": "test",
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+	}
+
+	claims, err := authenticator.ParseAndValidate(sign(validClaims()))
+	assert.NilError(t, err)
+	subject, err := claims.GetSubject()
+	assert.NilError(t, err)
+	assert.Equal(t, "test", subject)
+
+	testCases := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+		errMsg string
+	}{
+		{
+			name:   "wrong issuer",
+			modify: func(c jwt.MapClaims) { c["iss"] = "http://dex:32000" },
+			errMsg: "token has invalid issuer",
+		},
+		{
+			name:   "wrong audience",
+			modify: func(c jwt.MapClaims) { c["aud"] = "account" },
+			errMsg: "token audience [account] is not accepted",
+		},
+		{
+			name:   "wrong authorized party",
+			modify: func(c jwt.MapClaims) { c["azp"] = "other-client" },
+			errMsg: `token authorized party "other-client" is not accepted`,
+		},
+		{
+			name:   "not yet valid beyond clock skew",
+			modify: func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(5 * time.Minute).Unix() },
+			errMsg: "token is not valid yet",
+		},
+		{
+			name:   "expired beyond clock skew",
+			modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-5 * time.Minute).Unix() },
+			errMsg: "token is expired",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := validClaims()
+			tc.modify(c)
+			_, err := authenticator.ParseAndValidate(sign(c))
+			assert.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+
+	// Expiry and not-before within the clock skew are accepted
+	c := validClaims()
+	c["nbf"] = time.Now().Add(30 * time.Second).Unix()
+	c["exp"] = time.Now().Add(-30 * time.Second).Unix()
+	_, err = authenticator.ParseAndValidate(sign(c))
+	assert.NilError(t, err)
+}
+
+func TestNewJwtAuthenticator_IndependentKeyCaches(t *testing.T) {
+	key1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	key2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	// Both providers publish the same key ID with different keys
+	ts1 := startJwksServer(t, jose.JSONWebKey{Key: &key1.PublicKey, KeyID: "shared-kid", Use: "sig"})
+	defer ts1.Close()
+	ts2 := startJwksServer(t, jose.JSONWebKey{Key: &key2.PublicKey, KeyID: "shared-kid", Use: "sig"})
+	defer ts2.Close()
+
+	authenticator1 := NewJwtAuthenticator(WithDiscoveryURL(ts1.URL))
+	authenticator2 := NewJwtAuthenticator(WithDiscoveryURL(ts2.URL))
+
+	token1 := signTestToken(t, jwt.SigningMethodES256, "shared-kid", key1)
+	token2 := signTestToken(t, jwt.SigningMethodES256, "shared-kid", key2)
+
+	_, err = authenticator1.ParseAndValidate(token1)
+	assert.NilError(t, err)
+	_, err = authenticator2.ParseAndValidate(token2)
+	assert.NilError(t, err)
+
+	_, err = authenticator1.ParseAndValidate(token2)
+	assert.ErrorContains(t, err, "token signature is invalid")
+	_, err = authenticator2.ParseAndValidate(token1)
+	assert.ErrorContains(t, err, "token signature is invalid")
+}
+
+func TestNewJwtAuthenticator_SharedSecret(t *testing.T) {
+	authenticator := NewJwtAuthenticator(WithSharedSecret([]byte(sharedSecretKey)))
+	_, err := authenticator.ParseAndValidate(sampleTokenHS256Signature)
+	assert.NilError(t, err)
+
+	// Without a shared secret HS tokens are rejected rather than checked against an empty key
+	authenticator = NewJwtAuthenticator(WithSharedSecret([]byte{}), WithAllowedAlgorithms("HS256"))
+	_, err = authenticator.ParseAndValidate(sampleTokenHS256Signature)
+	assert.ErrorContains(t, err, "no shared secret configured")
+
+	// The process wide secret is only used by the zero value authenticator
+	t.Setenv(SharedSecretKey, sharedSecretKey)
+	_, err = new(JwtAuthenticator).ParseAndValidate(sampleTokenHS256Signature)
+	assert.NilError(t, err)
+	_, err = NewJwtAuthenticator().ParseAndValidate(sampleTokenHS256Signature)
+	assert.ErrorContains(t, err, "signing method HS256 is invalid")
+	_, err = NewJwtAuthenticator(WithAllowedAlgorithms("HS256")).ParseAndValidate(sampleTokenHS256Signature)
+	assert.ErrorContains(t, err, "no shared secret configured")
+}
+
+func TestUnverifiedExpiry(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": exp.Unix()}).SignedString([]byte("any key"))
+	assert.NilError(t, err)
+	assert.Assert(t, UnverifiedExpiry(token).Equal(exp))
+
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "test"}).SignedString([]byte("any key"))
+	assert.NilError(t, err)
+	assert.Assert(t, UnverifiedExpiry(token).IsZero())
+	assert.Assert(t, UnverifiedExpiry("opaque-token").IsZero())
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"gotest.tools/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testKeycloakServer is a Keycloak serving the admin login and the clients admin API of the master realm
+type testKeycloakServer struct {
+	*httptest.Server
+	clients      []KeycloakClient
+	secret       string
+	adminStatus  int
+	clientStatus int
+}
+
+func newTestKeycloakServer() *testKeycloakServer {
+	k := &testKeycloakServer{
+		clients: []KeycloakClient{{ID: "3f1c", ClientID: DefaultM2MClient}},
+		secret:  "m2m-secret",
+	}
+	k.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fmt.Sprintf(keycloakTokenURL, DefaultKeycloakRealm):
+			if r.FormValue("username") != "admin" || r.FormValue("password") != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"admin-token","expires_in":60}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer admin-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if k.adminStatus != 0 {
+			w.WriteHeader(k.adminStatus)
+			return
+		}
+		clientsURL := fmt.Sprintf("/admin/realms/%s/clients", DefaultKeycloakRealm)
+		switch r.URL.Path {
+		case clientsURL:
+			clients := []KeycloakClient{}
+			for _, c := range k.clients {
+				if c.ClientID == r.URL.Query().Get("clientId") {
+					clients = append(clients, c)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(clients)
+		case clientsURL + "/3f1c/client-secret":
+			_ = json.NewEncoder(w).Encode(KeycloakSecret{Type: "secret", Value: k.secret})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return k
+}
+
+func newTestKeycloakVaultAuth(t *testing.T, keycloak *testKeycloakServer, vault *testKVServer) VaultAuth {
+	t.Setenv("USE_M2M_TOKEN", "true")
+	v, err := NewVaultAuth(keycloak.URL, vault.URL, "test-svc",
+		WithServiceAccountTokenFile("testdata/k8stoken"), WithVaultKVMount("kv"))
+	assert.NilError(t, err)
+	return v
+}
+
+func TestCreateClientSecret(t *testing.T) {
+	keycloak := newTestKeycloakServer()
+	defer keycloak.Close()
+	vault := newTestKVServer(t)
+	defer vault.Close()
+	v := newTestKeycloakVaultAuth(t, keycloak, vault)
+
+	secret, err := v.CreateClientSecret(context.Background(), "admin", "pass")
+	assert.NilError(t, err)
+	assert.Equal(t, "m2m-secret", secret)
+
+	stored, _, err := ReadVaultKV[ClientSecretData](context.Background(), v.KV(""), DefaultM2MSecretPath)
+	assert.NilError(t, err)
+	assert.Equal(t, "3f1c", stored.ClientID)
+	assert.Equal(t, "m2m-secret", stored.ClientSecret)
+}
+
+func TestCreateClientSecretErrors(t *testing.T) {
+	vault := newTestKVServer(t)
+	defer vault.Close()
+
+	testCases := []struct {
+		name     string
+		password string
+		setup    func(k *testKeycloakServer)
+		check    func(err error) bool
+	}{
+		{
+			name:     "bad admin login",
+			password: "wrong",
+			check:    errors.IsUnauthorized,
+		},
+		{
+			name:     "client not found",
+			password: "pass",
+			setup:    func(k *testKeycloakServer) { k.clients = nil },
+			check:    errors.IsNotFound,
+		},
+		{
+			name:     "admin forbidden",
+			password: "pass",
+			setup:    func(k *testKeycloakServer) { k.adminStatus = http.StatusForbidden },
+			check:    errors.IsForbidden,
+		},
+		{
+			name:     "keycloak error",
+			password: "pass",
+			setup:    func(k *testKeycloakServer) { k.adminStatus = http.StatusServiceUnavailable },
+			check:    errors.IsUnavailable,
+		},
+		{
+			name:     "keycloak unreachable",
+			password: "pass",
+			setup:    func(k *testKeycloakServer) { k.Close() },
+			check:    errors.IsUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keycloak := newTestKeycloakServer()
+			defer keycloak.Close()
+			if tc.setup != nil {
+				tc.setup(keycloak)
+			}
+			v := newTestKeycloakVaultAuth(t, keycloak, vault)
+
+			secret, err := v.CreateClientSecret(context.Background(), "admin", tc.password)
+			assert.Assert(t, tc.check(err), "unexpected error %v", err)
+			assert.Equal(t, "", secret)
+
+			_, _, err = ReadVaultKV[ClientSecretData](context.Background(), v.KV(""), DefaultM2MSecretPath)
+			assert.Assert(t, errors.IsNotFound(err))
+		})
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	vault "github.com/hashicorp/vault/api"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"github.com/open-edge-platform/orch-library/go/pkg/keycloak"
+	"golang.org/x/sync/singleflight"
 	"io"
 	"net/http"
 	"net/url"
@@ -20,15 +22,23 @@ import (
 )
 
 const (
-	keycloakTokenURL       = "/realms/master/protocol/openid-connect/token"
-	keycloakAdminClient    = "system-client"
-	keycloakUserClientName = "edge-manager-m2m-client"
+	// DefaultKeycloakRealm is the default Keycloak realm of the admin and M2M clients
+	DefaultKeycloakRealm = "master"
+	// DefaultKeycloakAdminClient is the default Keycloak client used to obtain an admin token
+	DefaultKeycloakAdminClient = "system-client"
+	// DefaultM2MClient is the default Keycloak client of the M2M token
+	DefaultM2MClient = "edge-manager-m2m-client"
+	// DefaultM2MSecretPath is the default path of the M2M client secret in the Vault KV mount
+	DefaultM2MSecretPath = "catalog-bootstrap-m2m-client-secret"
+	// DefaultVaultKVMount is the default Vault KV version 2 secrets engine mount
+	DefaultVaultKVMount = "secret"
+
+	keycloakTokenURL = "/realms/%s/protocol/openid-connect/token"
 
 	vaultK8STokenFile  = `/var/run/secrets/kubernetes.io/serviceaccount/token` // #nosec G101
 	vaultK8SLoginURL   = `/v1/auth/kubernetes/login`
-	vaultSecretBaseURL = `/v1/secret/data/`           // #nosec
+	vaultKVURL         = `/v1/%s/%s/%s`               // #nosec
 	vaultRevokeSelfURL = `/v1/auth/token/revoke-self` // #nosec
-	m2mVaultSecretPath = "catalog-bootstrap-m2m-client-secret"
 )
 
 var (
@@ -47,6 +57,8 @@ type VaultAuth interface {
 	GetVaultToken(ctx context.Context) (string, error)
 	GetM2MToken(ctx context.Context) (string, error)
 	CreateClientSecret(ctx context.Context, username string, password string) (string, error)
+	KV(mount string) *VaultKV
+	LeaseStatus() VaultLeaseStatus
 	Logout(ctx context.Context) error
 }
 
@@ -55,21 +67,106 @@ type vaultAuth struct {
 	vaultServer    string
 	serviceAccount string
 	httpClient     *http.Client
-	vaultToken     string
-	mu             sync.Mutex
+	login          vaultLogin
+	session        *vaultSession
+
+	realm         string
+	adminClient   string
+	m2mClient     string
+	m2mSecretPath string
+	kvMount       string
+	// tokenFile is the service account token file; if empty K8STokenFile is read
+	tokenFile string
+
+	m2mRefreshMargin time.Duration
+	m2mGroup         singleflight.Group
+	m2mMu            sync.Mutex
+	m2mToken         *m2mToken
+	m2mTokenUsed     bool
+	m2mTimer         *time.Timer
+	// m2mGeneration is incremented by clearM2MToken, so that refreshes in flight do not cache their token
+	m2mGeneration uint64
 }
 
-func NewVaultAuth(keycloakServer string, vaultServer string, serviceAccount string) (VaultAuth, error) {
+// VaultAuthOption configures the VaultAuth created by NewVaultAuth
+type VaultAuthOption func(*vaultAuth)
+
+// WithKeycloakRealm sets the Keycloak realm of the admin and M2M clients
+func WithKeycloakRealm(realm string) VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.realm = realm
+	}
+}
+
+// WithKeycloakAdminClient sets the Keycloak client used to obtain an admin token in CreateClientSecret
+func WithKeycloakAdminClient(clientID string) VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.adminClient = clientID
+	}
+}
+
+// WithM2MClient sets the Keycloak client of the M2M token
+func WithM2MClient(clientID string) VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.m2mClient = clientID
+	}
+}
+
+// WithM2MSecretPath sets the path of the M2M client secret in the Vault KV mount
+func WithM2MSecretPath(path string) VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.m2mSecretPath = strings.Trim(path, "/")
+	}
+}
+
+// WithVaultKVMount sets the mount of the Vault KV version 2 secrets engine holding the M2M client secret
+func WithVaultKVMount(mount string) VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.kvMount = strings.Trim(mount, "/")
+	}
+}
+
+// WithServiceAccountTokenFile sets the Kubernetes service account token file used to log in to Vault
+func WithServiceAccountTokenFile(path string) VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.tokenFile = path
+	}
+}
+
+// WithM2MTokenRefreshMargin sets how long before its expiry a cached M2M token is no longer handed out
+func WithM2MTokenRefreshMargin(margin time.Duration) VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.m2mRefreshMargin = margin
+	}
+}
+
+// NewVaultAuth creates a VaultAuth logging in to Vault with the Kubernetes role serviceAccount, or with
+// the login method of the WithAppRoleLogin, WithTokenFromEnv or WithTokenFromFile option.
+// Without options the M2M client and its secret are those of the catalog bootstrap in the master realm.
+func NewVaultAuth(keycloakServer string, vaultServer string, serviceAccount string, opts ...VaultAuthOption) (VaultAuth, error) {
 	client, err := getHTTPClient()
 	if err != nil {
 		return nil, err
 	}
 	auth := &vaultAuth{
-		httpClient:     client,
-		keycloakServer: keycloakServer,
-		vaultServer:    vaultServer,
-		serviceAccount: serviceAccount,
-	}
+		httpClient:       client,
+		keycloakServer:   keycloakServer,
+		vaultServer:      vaultServer,
+		serviceAccount:   serviceAccount,
+		realm:            DefaultKeycloakRealm,
+		adminClient:      DefaultKeycloakAdminClient,
+		m2mClient:        DefaultM2MClient,
+		m2mSecretPath:    DefaultM2MSecretPath,
+		kvMount:          DefaultVaultKVMount,
+		m2mRefreshMargin: DefaultM2MTokenRefreshMargin,
+		login:            kubernetesLogin,
+	}
+	for _, opt := range opts {
+		opt(auth)
+	}
+	auth.session = newVaultSession(vaultServer, client, func(ctx context.Context) (*vaultAuthResponse, error) {
+		return auth.login.login(ctx, auth)
+	}, auth.login.revocable)
 	return auth, nil
 }
 
@@ -83,13 +180,33 @@ func (v *vaultAuth) httpsVaultURL(path string) string {
 	return v.vaultServer + path
 }
 
-// GetVaultToken reads and returns the secret token to access the vault
+func (v *vaultAuth) keycloakTokenURL() string {
+	return v.keycloakServer + fmt.Sprintf(keycloakTokenURL, url.PathEscape(v.realm))
+}
+
+func (v *vaultAuth) serviceAccountTokenFile() string {
+	if v.tokenFile != "" {
+		return v.tokenFile
+	}
+	return K8STokenFile
+}
+
+// GetVaultToken returns the token to access the vault. The token is obtained by a single
+// login and its lease is renewed in the background until Logout.
 func (v *vaultAuth) GetVaultToken(ctx context.Context) (string, error) {
-	v.mu.Lock()
-	defer v.mu.Unlock()
-	tokenData, err := os.ReadFile(K8STokenFile)
+	return v.session.getToken(ctx)
+}
+
+// LeaseStatus returns the state of the Vault token lease
+func (v *vaultAuth) LeaseStatus() VaultLeaseStatus {
+	return v.session.leaseStatus()
+}
+
+// kubernetesLogin logs in to Vault with the Kubernetes service account token
+func (v *vaultAuth) kubernetesLogin(ctx context.Context) (*vaultAuthResponse, error) {
+	tokenData, err := os.ReadFile(v.serviceAccountTokenFile())
 	if err != nil {
-		return "", err
+		return nil, errors.NewInvalid("unable to read the service account token: %v", err)
 	}
 	loginReq := struct {
 		JWT  string `json:"jwt"`
@@ -106,35 +223,25 @@ func (v *vaultAuth) GetVaultToken(ctx context.Context) (string, error) {
 		bytes.NewReader(body),
 	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 	resp, err := v.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return nil, vaultRequestError(ctx, "vault login request", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("vault login request failed %d", resp.StatusCode)
-	}
-
-	var loginResp struct {
-		Auth struct {
-			ClientToken string `json:"client_token"`
-		} `json:"auth"`
-		Errors []string `json:"errors"`
+		return nil, vaultLoginStatusError("vault login request", resp.StatusCode)
 	}
-	err = json.NewDecoder(resp.Body).Decode(&loginResp)
 
+	loginResp := &vaultAuthResponse{}
+	err = json.NewDecoder(resp.Body).Decode(loginResp)
 	if err != nil {
-		return "", err
+		return nil, errors.NewInternal("unable to decode vault login response: %v", err)
 	}
-	if loginResp.Auth.ClientToken == "" {
-		return "", fmt.Errorf("unable to get client token: %v", loginResp)
-	}
-	v.vaultToken = loginResp.Auth.ClientToken
-	return v.vaultToken, nil
+	return loginResp, nil
 }
 
 type ClientSecretData struct {
@@ -143,95 +250,34 @@ type ClientSecretData struct {
 }
 
 // getClientSecretFromVault reads and returns the client secret from the vault
-func (v *vaultAuth) getClientSecretFromVault(ctx context.Context, httpClient *http.Client, vaultClientToken string) (string, error) {
-	vaultURL := v.httpsVaultURL(vaultSecretBaseURL + m2mVaultSecretPath)
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		vaultURL,
-		nil,
-	)
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Add("X-Vault-Token", vaultClientToken)
-
-	resp, err := httpClient.Do(req)
+func (v *vaultAuth) getClientSecretFromVault(ctx context.Context) (string, error) {
+	secretData, _, err := ReadVaultKV[ClientSecretData](ctx, v.KV(""), v.m2mSecretPath)
 	if err != nil {
 		return "", err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	getRawData, err := readAllFactory(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	var secret vault.Secret
-	err = json.Unmarshal(getRawData, &secret)
-	if err != nil {
-		return "", err
-	}
-
-	type VaultPathGetResponse struct {
-		Data struct {
-			Data struct {
-				ClientID     string `json:"client_id"`
-				ClientSecret string `json:"client_secret"`
-			} `json:"data"`
-		} `json:"data"`
-	}
-	var getResult VaultPathGetResponse
-	err = json.Unmarshal(getRawData, &getResult)
-	if err != nil {
-		return "", err
-	}
-
-	return getResult.Data.Data.ClientSecret, nil
+	return secretData.ClientSecret, nil
 }
 
-func (v *vaultAuth) getAdminTokenFromKeycloak(ctx context.Context, httpClient *http.Client, adminUsername string, adminPassword string) (string, error) {
-	keycloakURL := v.keycloakServer + keycloakTokenURL
-	vals := url.Values{}
-	vals.Add("grant_type", "password")
-	vals.Add("client_id", keycloakAdminClient)
-	vals.Add("username", adminUsername)
-	vals.Add("password", adminPassword)
-	vals.Add("scope", "openid profile email groups")
-
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		keycloakURL, strings.NewReader(vals.Encode()),
-	)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	resp, err := httpClient.Do(req)
-
-	if err != nil {
-		return "", err
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("keycloak token request failed %d", resp.StatusCode)
-	}
-
-	var tokenResp struct {
-		AccessToken string `json:"access_token"`
-	}
-
-	err = json.NewDecoder(resp.Body).Decode(&tokenResp)
-	if err != nil {
-		return "", err
-	}
-	return tokenResp.AccessToken, nil
-
+// keycloakStatusError maps the status of a failed Keycloak response to a typed error
+func keycloakStatusError(statusCode int, msg string, args ...interface{}) error {
+	switch {
+	case statusCode == http.StatusNotFound:
+		return errors.NewNotFound(msg, args...)
+	case statusCode == http.StatusUnauthorized:
+		return errors.NewUnauthorized(msg, args...)
+	case statusCode == http.StatusForbidden:
+		return errors.NewForbidden(msg, args...)
+	case statusCode == http.StatusBadRequest:
+		return errors.NewInvalid(msg, args...)
+	case statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError:
+		return errors.NewUnavailable(msg, args...)
+	}
+	return errors.NewUnknown(msg, args...)
 }
 
+// KeycloakClient is the Keycloak client representation
+//
+// Deprecated: use keycloak.Client of the pkg/keycloak admin client
 type KeycloakClient struct {
 	ID                           string        `json:"id"`
 	ClientID                     string        `json:"clientId"`
@@ -289,176 +335,71 @@ type KeycloakClient struct {
 	} `json:"access"`
 }
 
-func (v *vaultAuth) getClientIDTokenFromKeycloak(ctx context.Context, httpClient *http.Client, adminToken string) (string, error) {
-	keycloakURL := v.keycloakServer + "/admin/realms/master/clients?clientId=" + keycloakUserClientName
-
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		keycloakURL, nil,
-	)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", "Bearer "+adminToken)
-
-	resp, err := httpClient.Do(req)
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("keycloak client id request failed %d", resp.StatusCode)
-	}
-
-	if err != nil {
-		return "", err
-	}
-
-	clientResp := []KeycloakClient{}
-
-	err = json.NewDecoder(resp.Body).Decode(&clientResp)
-	if err != nil {
-		return "", err
-	}
-
-	clientID := clientResp[0].ID
-
-	return clientID, nil
-}
-
+// KeycloakSecret is the Keycloak client secret representation
+//
+// Deprecated: use keycloak.Credential of the pkg/keycloak admin client
 type KeycloakSecret struct {
 	Type  string `json:"type"`
 	Value string `json:"value"`
 }
 
-func (v *vaultAuth) getSecretFromKeycloak(ctx context.Context, httpClient *http.Client, clientID string, adminToken string) (string, error) {
-	keycloakURL := v.keycloakServer + "/admin/realms/master/clients/" + clientID + "/client-secret"
-
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		keycloakURL, nil,
-	)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", "Bearer "+adminToken)
-
-	resp, err := httpClient.Do(req)
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("keycloak secret request failed %d", resp.StatusCode)
-	}
-
+// getClientSecretFromKeycloak reads and returns the ID and secret of the M2M client from keycloak
+func (v *vaultAuth) getClientSecretFromKeycloak(ctx context.Context, httpClient *http.Client, adminUsername string, adminPassword string) (string, string, error) {
+	admin, err := keycloak.NewAdminClient(v.keycloakServer, v.realm,
+		keycloak.WithHTTPClient(httpClient),
+		keycloak.WithLoginRealm(v.realm),
+		keycloak.WithPasswordLogin(v.adminClient, adminUsername, adminPassword))
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	secretResp := KeycloakSecret{}
-
-	err = json.NewDecoder(resp.Body).Decode(&secretResp)
+	client, err := admin.GetClientByClientID(ctx, v.m2mClient)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	clientSecret := secretResp.Value
-
-	return clientSecret, nil
-}
-
-// getClientSecretFromKeycloak reads and returns the client secret from the vault
-func (v *vaultAuth) getClientSecretFromKeycloak(ctx context.Context, httpClient *http.Client, adminUsername string, adminPassword string) (string, string, error) {
-	adminToken, err := v.getAdminTokenFromKeycloak(ctx, httpClient, adminUsername, adminPassword)
+	secret, err := admin.GetClientSecret(ctx, client.ID)
 	if err != nil {
 		return "", "", err
 	}
 
-	clientID, _ := v.getClientIDTokenFromKeycloak(ctx, httpClient, adminToken)
-
-	secret, err := v.getSecretFromKeycloak(ctx, httpClient, clientID, adminToken)
-
-	return clientID, secret, err
+	return client.ID, secret, nil
 }
 
 // setClientSecretToVault stores the client secret in the vault
-func (v *vaultAuth) setClientSecretToVault(ctx context.Context, httpClient *http.Client, vaultClientToken string, adminUsername string, adminPassword string) (string, error) {
+func (v *vaultAuth) setClientSecretToVault(ctx context.Context, httpClient *http.Client, adminUsername string, adminPassword string) (string, error) {
 	clientID, clientSecret, err := v.getClientSecretFromKeycloak(ctx, httpClient, adminUsername, adminPassword)
-
 	if err != nil {
 		return "", err
 	}
-	vaultURL := v.httpsVaultURL(vaultSecretBaseURL + m2mVaultSecretPath)
-
-	type VaultSecretDataData struct {
-		ClientID     string `json:"client_id"`
-		ClientSecret string `json:"client_secret"`
-	}
-	type VaultSetSecret struct {
-		Data VaultSecretDataData `json:"data"`
-	}
-	clientSecretData := VaultSetSecret{
-		Data: VaultSecretDataData{
-			ClientID:     clientID,
-			ClientSecret: clientSecret,
-		},
-	}
 
-	configBody, err := json.Marshal(clientSecretData)
-	if err != nil {
-		return "", err
-	}
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		vaultURL,
-		bytes.NewReader(configBody),
-	)
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Add("X-Vault-Token", vaultClientToken)
-
-	resp, err := httpClient.Do(req)
+	_, err = v.KV("").Write(ctx, v.m2mSecretPath, ClientSecretData{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	})
 	if err != nil {
 		return "", err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	getRawData, err := readAllFactory(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	var secret vault.Secret
-	err = json.Unmarshal(getRawData, &secret)
-	if err != nil {
-		return "", err
-	}
-
-	type VaultPathGetResponse struct {
-		Data struct {
-			Data struct {
-				ClientID     string `json:"client_id"`
-				ClientSecret string `json:"client_secret"`
-			} `json:"data"`
-		} `json:"data"`
-	}
-	var getResult VaultPathGetResponse
-	err = json.Unmarshal(getRawData, &getResult)
-	if err != nil {
-		return "", err
-	}
-	return getResult.Data.Data.ClientSecret, nil
+	return clientSecret, nil
 }
 
 // getM2MTokenFromKeycloak reads and returns the M2M token from keycloak
-func (v *vaultAuth) getM2MTokenFromKeycloak(ctx context.Context, httpClient *http.Client, clientSecret string) (string, error) {
-	keycloakURL := v.keycloakServer + keycloakTokenURL
+func (v *vaultAuth) getM2MTokenFromKeycloak(ctx context.Context, httpClient *http.Client, clientSecret string) (*m2mTokenResponse, error) {
 	vals := url.Values{}
 	vals.Add("grant_type", "client_credentials")
+	return v.requestM2MToken(ctx, httpClient, clientSecret, vals)
+}
+
+// refreshM2MTokenFromKeycloak exchanges the refresh token for a new M2M token
+func (v *vaultAuth) refreshM2MTokenFromKeycloak(ctx context.Context, httpClient *http.Client, clientSecret string, refreshToken string) (*m2mTokenResponse, error) {
+	vals := url.Values{}
+	vals.Add("grant_type", "refresh_token")
+	vals.Add("refresh_token", refreshToken)
+	return v.requestM2MToken(ctx, httpClient, clientSecret, vals)
+}
+
+func (v *vaultAuth) requestM2MToken(ctx context.Context, httpClient *http.Client, clientSecret string, vals url.Values) (*m2mTokenResponse, error) {
+	keycloakURL := v.keycloakTokenURL()
 
 	req, err := http.NewRequestWithContext(
 		ctx,
@@ -466,64 +407,79 @@ func (v *vaultAuth) getM2MTokenFromKeycloak(ctx context.Context, httpClient *htt
 		keycloakURL, strings.NewReader(vals.Encode()),
 	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	req.SetBasicAuth("edge-manager-m2m-client", clientSecret)
+	req.SetBasicAuth(v.m2mClient, clientSecret)
 
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return nil, errors.NewUnavailable("keycloak m2m token request failed: %v", err)
 	}
 
 	defer func() { _ = resp.Body.Close() }()
 
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return nil, errors.NewUnauthorized("keycloak m2m token request of %s failed %d", v.m2mClient, resp.StatusCode)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("keycloak m2m token request failed %d", resp.StatusCode)
+		return nil, keycloakStatusError(resp.StatusCode, "keycloak m2m token request failed %d", resp.StatusCode)
 	}
 
-	var tokenResp struct {
-		AccessToken string `json:"access_token"`
-	}
-	err = json.NewDecoder(resp.Body).Decode(&tokenResp)
+	tokenResp := &m2mTokenResponse{}
+	err = json.NewDecoder(resp.Body).Decode(tokenResp)
 	if err != nil {
-		return "", err
+		return nil, errors.NewInternal("unable to decode keycloak m2m token response: %v", err)
 	}
 
-	return tokenResp.AccessToken, nil
+	return tokenResp, nil
 }
 
+// GetM2MToken returns an M2M token of the M2M client. The token is cached until shortly before it
+// expires and refreshed in the background while it is in use, so that most calls do not reach
+// Vault or Keycloak. Concurrent calls that find no valid token share a single refresh.
 func (v *vaultAuth) GetM2MToken(ctx context.Context) (string, error) {
 	useM2MTokenString := os.Getenv("USE_M2M_TOKEN")
 	useM2MToken, err := strconv.ParseBool(useM2MTokenString)
 	if err != nil || !useM2MToken {
 		return "", nil
 	}
-	M2MToken := ""
 
-	// get vault token
-	vaultClient, err := getHTTPClient()
+	if token, ok := v.cachedM2MToken(); ok {
+		return token, nil
+	}
+	// The refresh is shared with other callers, so it must not be canceled with this caller
+	token, err, _ := v.m2mGroup.Do(m2mTokenRefreshKey, func() (interface{}, error) {
+		if token, ok := v.cachedM2MToken(); ok {
+			return token, nil
+		}
+		return v.refreshM2MToken(context.WithoutCancel(ctx), v.currentM2MGeneration())
+	})
 	if err != nil {
 		return "", err
 	}
-	vaultClientToken, err := v.GetVaultToken(ctx)
+	return token.(string), nil
+}
+
+// newM2MToken reads the client secret from Vault and requests a new M2M token from Keycloak
+func (v *vaultAuth) newM2MToken(ctx context.Context) (*m2mTokenResponse, string, error) {
+	vaultClient, err := getHTTPClient()
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
-	defer func() { _ = v.Logout(ctx) }()
 	// get client name/client secret from vault
-	clientSecret, err := v.getClientSecretFromVault(ctx, vaultClient, vaultClientToken)
+	clientSecret, err := v.getClientSecretFromVault(ctx)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
 	// read M2M token
-	M2MToken, err = v.getM2MTokenFromKeycloak(ctx, vaultClient, clientSecret)
+	tokenResp, err := v.getM2MTokenFromKeycloak(ctx, vaultClient, clientSecret)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
-	return M2MToken, nil
+	return tokenResp, clientSecret, nil
 }
 
 func (v *vaultAuth) CreateClientSecret(ctx context.Context, username string, password string) (string, error) {
@@ -534,19 +490,13 @@ func (v *vaultAuth) CreateClientSecret(ctx context.Context, username string, pas
 	}
 	M2MToken := ""
 
-	// get vault token
 	vaultClient, err := getHTTPClient()
 	if err != nil {
 		return "", err
 	}
-	vaultClientToken, err := v.GetVaultToken(ctx)
-	if err != nil {
-		return "", err
-	}
-	defer func() { _ = v.Logout(ctx) }()
 
 	// set client name/client secret in vault
-	M2MToken, err = v.setClientSecretToVault(ctx, vaultClient, vaultClientToken, username, password)
+	M2MToken, err = v.setClientSecretToVault(ctx, vaultClient, username, password)
 	if err != nil {
 		return "", err
 	}
@@ -554,34 +504,9 @@ func (v *vaultAuth) CreateClientSecret(ctx context.Context, username string, pas
 	return M2MToken, nil
 }
 
+// Logout revokes the Vault token and stops renewing it. The cached M2M token is dropped and no
+// longer refreshed in the background, so that nothing logs in to Vault again on its own.
 func (v *vaultAuth) Logout(ctx context.Context) error {
-	v.mu.Lock()
-	defer v.mu.Unlock()
-	if v.vaultToken == "" {
-		return nil
-	}
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		v.httpsVaultURL(vaultRevokeSelfURL),
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Set("X-Vault-Token", v.vaultToken)
-
-	resp, err := v.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusNoContent {
-		log.Infof("http error on revoke: %d", resp.StatusCode)
-		return fmt.Errorf("http error on revoke: %d", resp.StatusCode)
-	}
-	v.vaultToken = ""
-	return nil
+	v.clearM2MToken()
+	return v.session.revoke(ctx)
 }
@@ -6,11 +6,13 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/stretchr/testify/suite"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
 	"time"
 )
@@ -71,6 +73,8 @@ func (s *M2MTestSuite) NewTestHTTPServer() *TestHTTPServer {
 	}
 }
 
+const testM2MSecretURL = "/v1/kv/data/test-service/m2m"
+
 var (
 	VaultServer    string
 	KeycloakServer string
@@ -81,15 +85,15 @@ func (t *TestHTTPServer) Start() *TestHTTPServer {
 		switch r.URL.Path {
 		case vaultK8SLoginURL:
 			t.K8SLoginReadHandler(w)
-		case vaultSecretBaseURL + `catalog-bootstrap-m2m-client-secret`:
+		case fmt.Sprintf(vaultKVURL, DefaultVaultKVMount, vaultKVDataPrefix, DefaultM2MSecretPath), testM2MSecretURL:
 			t.SecretHandler(w, r)
 		case vaultRevokeSelfURL:
 			t.RevokeHandler(w)
-		case keycloakTokenURL:
+		case fmt.Sprintf(keycloakTokenURL, DefaultKeycloakRealm), fmt.Sprintf(keycloakTokenURL, "test-realm"):
 			t.KeycloakTokenHandler(w, r)
 		}
 	}))
-	secrets[vaultSecretBaseURL+`catalog-bootstrap-m2m-client-secret`] = `{"data":{"data":{"value":"` + `secret` + `"}}}`
+	secrets[fmt.Sprintf(vaultKVURL, DefaultVaultKVMount, vaultKVDataPrefix, DefaultM2MSecretPath)] = `{"data":{"data":{"value":"` + `secret` + `"}}}`
 	t.Server = server
 	VaultServer = server.URL
 	KeycloakServer = server.URL
@@ -209,6 +213,210 @@ func (s *M2MTestSuite) TestCreateClientSecretNoKeycloak() {
 	s.Equal("", secret)
 }
 
+// m2mTokenServer is a Keycloak token endpoint issuing numbered M2M tokens
+type m2mTokenServer struct {
+	mu               sync.Mutex
+	grantTypes       []string
+	expiresIn        int
+	refreshExpiresIn int
+	failRefresh      bool
+	// refreshing is closed when a refresh token grant is received, which is then held until release is closed
+	refreshing chan struct{}
+	release    chan struct{}
+}
+
+func (k *m2mTokenServer) handle(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	grantType := r.PostForm.Get("grant_type")
+	if grantType == "refresh_token" && k.release != nil {
+		close(k.refreshing)
+		<-k.release
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.grantTypes = append(k.grantTypes, grantType)
+	if grantType == "refresh_token" && k.failRefresh {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(m2mTokenResponse{
+		AccessToken:      fmt.Sprintf("token-%d", len(k.grantTypes)),
+		ExpiresIn:        k.expiresIn,
+		RefreshToken:     fmt.Sprintf("refresh-%d", len(k.grantTypes)),
+		RefreshExpiresIn: k.refreshExpiresIn,
+	})
+}
+
+func (k *m2mTokenServer) requests() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]string{}, k.grantTypes...)
+}
+
+func (s *M2MTestSuite) TestGetM2MTokenCached() {
+	keycloak := &m2mTokenServer{expiresIn: 300}
+	server := s.NewTestHTTPServer().WithKeycloakTokenHandler(keycloak.handle).Start()
+	defer server.Stop()
+
+	v, err := NewVaultAuth(KeycloakServer, VaultServer, "test-svc")
+	s.NoError(err)
+	s.NoError(os.Setenv("USE_M2M_TOKEN", "true"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := v.GetM2MToken(context.Background())
+			s.NoError(err)
+			s.Equal("token-1", token)
+		}()
+	}
+	wg.Wait()
+
+	token, err := v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token-1", token)
+	s.Equal([]string{"client_credentials"}, keycloak.requests())
+}
+
+func (s *M2MTestSuite) TestGetM2MTokenBackgroundRefresh() {
+	keycloak := &m2mTokenServer{expiresIn: 2, refreshExpiresIn: 60}
+	server := s.NewTestHTTPServer().WithKeycloakTokenHandler(keycloak.handle).Start()
+	defer server.Stop()
+
+	v, err := NewVaultAuth(KeycloakServer, VaultServer, "test-svc")
+	s.NoError(err)
+	s.NoError(os.Setenv("USE_M2M_TOKEN", "true"))
+
+	token, err := v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token-1", token)
+	// The token is in use, so it is refreshed in the background with the refresh token
+	token, err = v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token-1", token)
+
+	s.Eventually(func() bool {
+		return len(keycloak.requests()) == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	token, err = v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token-2", token)
+	s.Equal([]string{"client_credentials", "refresh_token"}, keycloak.requests())
+}
+
+func (s *M2MTestSuite) TestGetM2MTokenRefreshFailure() {
+	keycloak := &m2mTokenServer{expiresIn: 2, failRefresh: true}
+	server := s.NewTestHTTPServer().WithKeycloakTokenHandler(keycloak.handle).Start()
+	defer server.Stop()
+
+	v, err := NewVaultAuth(KeycloakServer, VaultServer, "test-svc")
+	s.NoError(err)
+	s.NoError(os.Setenv("USE_M2M_TOKEN", "true"))
+
+	token, err := v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token-1", token)
+
+	// An unused token is not refreshed in the background
+	time.Sleep(1100 * time.Millisecond)
+	s.Equal([]string{"client_credentials"}, keycloak.requests())
+
+	// Once stale, a failed refresh token grant falls back to the client credentials
+	token, err = v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token-3", token)
+	s.Equal([]string{"client_credentials", "refresh_token", "client_credentials"}, keycloak.requests())
+}
+
+func (s *M2MTestSuite) TestGetM2MTokenLogout() {
+	keycloak := &m2mTokenServer{expiresIn: 2, refreshExpiresIn: 60}
+	server := s.NewTestHTTPServer().WithKeycloakTokenHandler(keycloak.handle).Start()
+	defer server.Stop()
+
+	v, err := NewVaultAuth(KeycloakServer, VaultServer, "test-svc")
+	s.NoError(err)
+	s.NoError(os.Setenv("USE_M2M_TOKEN", "true"))
+
+	token, err := v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token-1", token)
+	token, err = v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token-1", token)
+
+	// After a logout the token in use is neither refreshed in the background nor handed out
+	s.NoError(v.Logout(context.Background()))
+	time.Sleep(1100 * time.Millisecond)
+	s.Equal([]string{"client_credentials"}, keycloak.requests())
+	token, err = v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token-2", token)
+	s.Equal([]string{"client_credentials", "client_credentials"}, keycloak.requests())
+}
+
+func (s *M2MTestSuite) TestGetM2MTokenLogoutDuringRefresh() {
+	keycloak := &m2mTokenServer{expiresIn: 2, refreshExpiresIn: 60,
+		refreshing: make(chan struct{}), release: make(chan struct{})}
+	server := s.NewTestHTTPServer().WithKeycloakTokenHandler(keycloak.handle).Start()
+	defer server.Stop()
+
+	v, err := NewVaultAuth(KeycloakServer, VaultServer, "test-svc")
+	s.NoError(err)
+	s.NoError(os.Setenv("USE_M2M_TOKEN", "true"))
+
+	_, err = v.GetM2MToken(context.Background())
+	s.NoError(err)
+	_, err = v.GetM2MToken(context.Background())
+	s.NoError(err)
+
+	// A background refresh completing after the logout neither caches its token nor schedules another refresh
+	<-keycloak.refreshing
+	s.NoError(v.Logout(context.Background()))
+	close(keycloak.release)
+	s.Eventually(func() bool {
+		return len(keycloak.requests()) == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	// Joining the refresh in flight waits for it to complete
+	vault := v.(*vaultAuth)
+	_, _, _ = vault.m2mGroup.Do(m2mTokenRefreshKey, func() (interface{}, error) { return "", nil })
+	vault.m2mMu.Lock()
+	s.Nil(vault.m2mToken)
+	s.Nil(vault.m2mTimer)
+	vault.m2mMu.Unlock()
+}
+
+func (s *M2MTestSuite) TestGetM2MTokenWithOptions() {
+	var tokenPaths, clientIDs []string
+	server := s.NewTestHTTPServer().WithKeycloakTokenHandler(func(w http.ResponseWriter, r *http.Request) {
+		clientID, _, _ := r.BasicAuth()
+		tokenPaths = append(tokenPaths, r.URL.Path)
+		clientIDs = append(clientIDs, clientID)
+		s.handleKeycloakToken(w, r)
+	}).Start()
+	defer server.Stop()
+	secrets[testM2MSecretURL] = `{"data":{"data":{"client_secret":"test-secret"}}}`
+	// The configured token file is read instead of K8STokenFile
+	K8STokenFile = "testdata/missing"
+	defer func() { K8STokenFile = "testdata/k8stoken" }()
+
+	v, err := NewVaultAuth(KeycloakServer, VaultServer, "test-svc",
+		WithKeycloakRealm("test-realm"),
+		WithM2MClient("test-m2m-client"),
+		WithVaultKVMount("/kv/"),
+		WithM2MSecretPath("test-service/m2m"),
+		WithServiceAccountTokenFile("testdata/k8stoken"))
+	s.NoError(err)
+	s.NoError(os.Setenv("USE_M2M_TOKEN", "true"))
+	token, err := v.GetM2MToken(context.Background())
+	s.NoError(err)
+	s.Equal("token", token)
+	s.Equal([]string{"/realms/test-realm/protocol/openid-connect/token"}, tokenPaths)
+	s.Equal([]string{"test-m2m-client"}, clientIDs)
+}
+
 func TestM2M(t *testing.T) {
 	suite.Run(t, &M2MTestSuite{})
 }
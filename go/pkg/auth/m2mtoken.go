@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultM2MTokenRefreshMargin is how long before its expiry a cached M2M token is no longer
+	// handed out; it is capped at half the lifetime of the token
+	DefaultM2MTokenRefreshMargin = 30 * time.Second
+
+	m2mTokenRefreshKey = "m2m"
+)
+
+type m2mTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+// m2mToken is a cached M2M token
+type m2mToken struct {
+	accessToken  string
+	refreshToken string
+	// clientSecret is kept to authenticate the refresh token grant without logging in to Vault
+	clientSecret string
+	obtained     time.Time
+	expires      time.Time
+	// refreshExpires is zero if the refresh token does not expire
+	refreshExpires time.Time
+}
+
+// staleAt is the time after which the token is no longer handed out
+func (t *m2mToken) staleAt(margin time.Duration) time.Time {
+	lifetime := t.expires.Sub(t.obtained)
+	if margin > lifetime/2 {
+		margin = lifetime / 2
+	}
+	return t.expires.Add(-margin)
+}
+
+// preRefreshAt is the time at which the token is refreshed in the background if it is in use
+func (t *m2mToken) preRefreshAt(margin time.Duration) time.Time {
+	staleAt := t.staleAt(margin)
+	halfLife := t.obtained.Add(t.expires.Sub(t.obtained) / 2)
+	preRefreshAt := staleAt.Add(-staleAt.Sub(t.obtained) / 4)
+	if preRefreshAt.Before(halfLife) {
+		return halfLife
+	}
+	return preRefreshAt
+}
+
+func (t *m2mToken) canRefresh(now time.Time) bool {
+	return t.refreshToken != "" && (t.refreshExpires.IsZero() || now.Before(t.refreshExpires))
+}
+
+// cachedM2MToken returns the cached token if it is not stale and marks it as in use
+func (v *vaultAuth) cachedM2MToken() (string, bool) {
+	v.m2mMu.Lock()
+	defer v.m2mMu.Unlock()
+	if v.m2mToken == nil || !time.Now().Before(v.m2mToken.staleAt(v.m2mRefreshMargin)) {
+		return "", false
+	}
+	v.m2mTokenUsed = true
+	return v.m2mToken.accessToken, true
+}
+
+// currentM2MGeneration returns the generation of the cached token, which clearM2MToken increments
+func (v *vaultAuth) currentM2MGeneration() uint64 {
+	v.m2mMu.Lock()
+	defer v.m2mMu.Unlock()
+	return v.m2mGeneration
+}
+
+// refreshM2MToken obtains a new token with the refresh token if there is a valid one, or else with
+// the client secret read from Vault, and caches it unless the cache was cleared since generation
+func (v *vaultAuth) refreshM2MToken(ctx context.Context, generation uint64) (string, error) {
+	v.m2mMu.Lock()
+	current := v.m2mToken
+	cleared := v.m2mGeneration != generation
+	v.m2mMu.Unlock()
+	if cleared {
+		return "", fmt.Errorf("M2M token cleared before refresh")
+	}
+
+	if current != nil && current.canRefresh(time.Now()) {
+		tokenResp, err := v.refreshM2MTokenFromKeycloak(ctx, v.httpClient, current.clientSecret, current.refreshToken)
+		if err == nil {
+			return v.storeM2MToken(tokenResp, current.clientSecret, generation), nil
+		}
+		log.Warnf("unable to refresh M2M token, requesting a new one: %v", err)
+	}
+
+	tokenResp, clientSecret, err := v.newM2MToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	return v.storeM2MToken(tokenResp, clientSecret, generation), nil
+}
+
+// storeM2MToken caches the token and schedules its background refresh. Tokens of unknown lifetime are not
+// cached, nor are tokens obtained while the cache was cleared, e.g. by Logout, since generation.
+func (v *vaultAuth) storeM2MToken(tokenResp *m2mTokenResponse, clientSecret string, generation uint64) string {
+	now := time.Now()
+	token := &m2mToken{
+		accessToken:  tokenResp.AccessToken,
+		refreshToken: tokenResp.RefreshToken,
+		clientSecret: clientSecret,
+		obtained:     now,
+	}
+	if tokenResp.ExpiresIn > 0 {
+		token.expires = now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	} else if exp := UnverifiedExpiry(tokenResp.AccessToken); !exp.IsZero() {
+		token.expires = exp
+	}
+	if tokenResp.RefreshExpiresIn > 0 {
+		token.refreshExpires = now.Add(time.Duration(tokenResp.RefreshExpiresIn) * time.Second)
+	}
+
+	v.m2mMu.Lock()
+	defer v.m2mMu.Unlock()
+	if v.m2mGeneration != generation {
+		return token.accessToken
+	}
+	if v.m2mTimer != nil {
+		v.m2mTimer.Stop()
+		v.m2mTimer = nil
+	}
+	if !token.expires.After(now) {
+		v.m2mToken = nil
+		return token.accessToken
+	}
+	v.m2mToken = token
+	v.m2mTokenUsed = false
+	v.m2mTimer = time.AfterFunc(time.Until(token.preRefreshAt(v.m2mRefreshMargin)), v.preRefreshM2MToken)
+	return token.accessToken
+}
+
+// clearM2MToken drops the cached token and stops its background refresh
+func (v *vaultAuth) clearM2MToken() {
+	v.m2mMu.Lock()
+	defer v.m2mMu.Unlock()
+	if v.m2mTimer != nil {
+		v.m2mTimer.Stop()
+		v.m2mTimer = nil
+	}
+	v.m2mToken = nil
+	v.m2mTokenUsed = false
+	v.m2mGeneration++
+}
+
+// preRefreshM2MToken refreshes the cached token ahead of its expiry, so that callers do not wait for
+// Keycloak. Tokens that were not used since they were obtained are left to expire.
+func (v *vaultAuth) preRefreshM2MToken() {
+	v.m2mMu.Lock()
+	used := v.m2mTokenUsed
+	generation := v.m2mGeneration
+	v.m2mMu.Unlock()
+	if !used {
+		return
+	}
+	_, err, _ := v.m2mGroup.Do(m2mTokenRefreshKey, func() (interface{}, error) {
+		return v.refreshM2MToken(context.Background(), generation)
+	})
+	if err != nil {
+		log.Warnf("unable to refresh M2M token in the background: %v", err)
+	}
+}
@@ -5,6 +5,8 @@ package mocks
 import (
 	context "context"
 
+	auth "github.com/open-edge-platform/orch-library/go/pkg/auth"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -97,6 +99,62 @@ func (_m *VaultAuth) GetVaultToken(ctx context.Context) (string, error) {
 	return r0, r1
 }
 
+// KV provides a mock function with given fields: mount
+func (_m *VaultAuth) KV(mount string) *auth.VaultKV {
+	ret := _m.Called(mount)
+
+	if len(ret) == 0 {
+		panic("no return value specified for KV")
+	}
+
+	var r0 *auth.VaultKV
+	if rf, ok := ret.Get(0).(func(string) *auth.VaultKV); ok {
+		r0 = rf(mount)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auth.VaultKV)
+		}
+	}
+
+	return r0
+}
+
+// LeaseStatus provides a mock function with given fields:
+func (_m *VaultAuth) LeaseStatus() auth.VaultLeaseStatus {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for LeaseStatus")
+	}
+
+	var r0 auth.VaultLeaseStatus
+	if rf, ok := ret.Get(0).(func() auth.VaultLeaseStatus); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(auth.VaultLeaseStatus)
+	}
+
+	return r0
+}
+
+// Logout provides a mock function with given fields: ctx
+func (_m *VaultAuth) Logout(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewVaultAuth creates a new instance of VaultAuth. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewVaultAuth(t interface {
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultiIssuerAuthenticator validates tokens issued by one of a set of trusted issuers.
+// The unverified "iss" claim selects the JwtAuthenticator, and therefore the discovery
+// document and JWKS, used to verify the token; tokens from any other issuer are rejected
+// before any key is fetched.
+type MultiIssuerAuthenticator struct {
+	authenticators map[string]*JwtAuthenticator
+}
+
+var _ Authenticator = &MultiIssuerAuthenticator{}
+
+// NewMultiIssuerAuthenticator creates a MultiIssuerAuthenticator from authenticators
+// created with NewJwtAuthenticator and WithIssuer, one per trusted issuer
+func NewMultiIssuerAuthenticator(authenticators ...*JwtAuthenticator) (*MultiIssuerAuthenticator, error) {
+	m := &MultiIssuerAuthenticator{
+		authenticators: make(map[string]*JwtAuthenticator, len(authenticators)),
+	}
+	for _, a := range authenticators {
+		if a.Issuer() == "" {
+			return nil, fmt.Errorf("authenticator has no issuer configured")
+		}
+		if _, ok := m.authenticators[a.Issuer()]; ok {
+			return nil, fmt.Errorf("duplicate issuer %s", a.Issuer())
+		}
+		m.authenticators[a.Issuer()] = a
+	}
+	return m, nil
+}
+
+// Issuers returns the trusted issuers
+func (m *MultiIssuerAuthenticator) Issuers() []string {
+	issuers := make([]string, 0, len(m.authenticators))
+	for issuer := range m.authenticators {
+		issuers = append(issuers, issuer)
+	}
+	return issuers
+}
+
+// ParseAndValidate parse a jwt string token and validate it with the authenticator of its issuer
+func (m *MultiIssuerAuthenticator) ParseAndValidate(tokenString string) (jwt.Claims, error) {
+	unverified := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, unverified); err != nil {
+		log.Warnf("cannot parse token. %s", err.Error())
+		return nil, status.Errorf(codes.Unauthenticated, "token is malformed: %v", err)
+	}
+	issuer, err := unverified.GetIssuer()
+	if err != nil || issuer == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "token has no issuer")
+	}
+
+	authenticator, ok := m.authenticators[issuer]
+	if !ok {
+		log.Warnf("token issuer %s is not trusted", issuer)
+		return nil, status.Errorf(codes.Unauthenticated, "token issuer %s is not trusted", issuer)
+	}
+	return authenticator.ParseAndValidate(tokenString)
+}
+
+// Authenticate implements Authenticator by parsing and validating a jwt string token
+func (m *MultiIssuerAuthenticator) Authenticate(tokenString string) (jwt.MapClaims, error) {
+	claims, err := m.ParseAndValidate(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return claims.(jwt.MapClaims), nil
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"gotest.tools/assert"
+	"testing"
+	"time"
+)
+
+func TestMultiIssuerAuthenticator(t *testing.T) {
+	centralKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	siteKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	central := newTestJwksServer(t, jose.JSONWebKey{Key: &centralKey.PublicKey, KeyID: "central", Use: "sig"})
+	defer central.Close()
+	site := newTestJwksServer(t, jose.JSONWebKey{Key: &siteKey.PublicKey, KeyID: "site", Use: "sig"})
+	defer site.Close()
+
+	authenticator, err := NewMultiIssuerAuthenticator(
+		NewJwtAuthenticator(WithIssuer(central.URL+"/realms/master"), WithDiscoveryURL(central.URL)),
+		NewJwtAuthenticator(WithIssuer(site.URL+"/realms/site"), WithDiscoveryURL(site.URL)),
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, 2, len(authenticator.Issuers()))
+
+	sign := func(issuer string, kid string, key *ecdsa.PrivateKey) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+			"iss": issuer,
+			"sub": "test",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = kid
+		s, err := token.SignedString(key)
+		assert.NilError(t, err)
+		return s
+	}
+
+	claims, err := authenticator.ParseAndValidate(sign(central.URL+"/realms/master", "central", centralKey))
+	assert.NilError(t, err)
+	issuer, err := claims.GetIssuer()
+	assert.NilError(t, err)
+	assert.Equal(t, central.URL+"/realms/master", issuer)
+
+	_, err = authenticator.ParseAndValidate(sign(site.URL+"/realms/site", "site", siteKey))
+	assert.NilError(t, err)
+	assert.Equal(t, int32(1), central.jwksFetches.Load())
+	assert.Equal(t, int32(1), site.jwksFetches.Load())
+
+	// A token claiming the site issuer but signed with the central key is rejected
+	_, err = authenticator.ParseAndValidate(sign(site.URL+"/realms/site", "central", centralKey))
+	assert.ErrorContains(t, err, "token has obsolete key ID central")
+
+	// Tokens from unknown issuers are rejected without fetching any keys
+	_, err = authenticator.ParseAndValidate(sign("https://evil.example.com", "evil", siteKey))
+	assert.ErrorContains(t, err, "token issuer https://evil.example.com is not trusted")
+	_, err = authenticator.ParseAndValidate(sign("", "central", centralKey))
+	assert.ErrorContains(t, err, "token has no issuer")
+	_, err = authenticator.ParseAndValidate("not-a-token")
+	assert.ErrorContains(t, err, "token is malformed")
+	assert.Equal(t, int32(1), central.jwksFetches.Load())
+	assert.Equal(t, int32(1), site.jwksFetches.Load())
+}
+
+func TestNewMultiIssuerAuthenticator_InvalidConfig(t *testing.T) {
+	_, err := NewMultiIssuerAuthenticator(NewJwtAuthenticator())
+	assert.ErrorContains(t, err, "no issuer configured")
+
+	_, err = NewMultiIssuerAuthenticator(
+		NewJwtAuthenticator(WithIssuer("https://keycloak/realms/master")),
+		NewJwtAuthenticator(WithIssuer("https://keycloak/realms/master")),
+	)
+	assert.ErrorContains(t, err, "duplicate issuer")
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+)
+
+const (
+	// RealmAccessClaim the Keycloak claim holding the realm roles
+	RealmAccessClaim = "realm_access"
+	// ResourceAccessClaim the Keycloak claim holding the roles per client
+	ResourceAccessClaim = "resource_access"
+	// GroupsClaim the Keycloak claim holding the groups
+	GroupsClaim = "groups"
+	// RolesClaim the key of the roles within the realm and resource access claims
+	RolesClaim = "roles"
+	// PreferredUsernameClaim the OIDC claim holding the username
+	PreferredUsernameClaim = "preferred_username"
+	// EmailClaim the OIDC claim holding the e-mail address
+	EmailClaim = "email"
+
+	// serviceAccountUsernamePrefix the prefix of the username of Keycloak service account users
+	serviceAccountUsernamePrefix = "service-account-"
+	// clientIDClaim the claim Keycloak adds to tokens issued with the client credentials grant
+	clientIDClaim = "client_id"
+	// projectRoleSeparator separates the project ID from the role name in project roles,
+	// e.g. "<project UUID>_<role>"
+	projectRoleSeparator = "_"
+)
+
+// Principal is the authenticated caller, parsed from the claims of a Keycloak token
+type Principal struct {
+	// Subject the "sub" claim
+	Subject string
+	// Username the "preferred_username" claim
+	Username string
+	// Email the "email" claim
+	Email string
+	// AuthorizedParty the "azp" claim, the client the token was issued to
+	AuthorizedParty string
+	// RealmRoles the realm_access.roles claim
+	RealmRoles []string
+	// ClientRoles the resource_access.<client>.roles claims by client
+	ClientRoles map[string][]string
+	// Groups the "groups" claim
+	Groups []string
+	// Claims all claims of the token
+	Claims jwt.MapClaims
+}
+
+// NewPrincipal parses the claims of a token. Claims that are missing or of an unexpected type are left empty.
+func NewPrincipal(claims jwt.MapClaims) *Principal {
+	p := &Principal{
+		ClientRoles: make(map[string][]string),
+		Claims:      claims,
+	}
+	p.Subject, _ = claims.GetSubject()
+	p.Username, _ = claims[PreferredUsernameClaim].(string)
+	p.Email, _ = claims[EmailClaim].(string)
+	p.AuthorizedParty, _ = claims[authorizedPartyClaim].(string)
+	p.Groups = stringSlice(claims[GroupsClaim])
+	if realmAccess, ok := claims[RealmAccessClaim].(map[string]interface{}); ok {
+		p.RealmRoles = stringSlice(realmAccess[RolesClaim])
+	}
+	if resourceAccess, ok := claims[ResourceAccessClaim].(map[string]interface{}); ok {
+		for client, access := range resourceAccess {
+			if access, ok := access.(map[string]interface{}); ok {
+				p.ClientRoles[client] = stringSlice(access[RolesClaim])
+			}
+		}
+	}
+	return p
+}
+
+// HasRole checks if the principal has the given realm role
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.RealmRoles, role)
+}
+
+// HasClientRole checks if the principal has the given role of the given client
+func (p *Principal) HasClientRole(client string, role string) bool {
+	return slices.Contains(p.ClientRoles[client], role)
+}
+
+// HasProjectRole checks if the principal has the given role in the given project,
+// i.e. the realm role "<projectID>_<role>"
+func (p *Principal) HasProjectRole(projectID string, role string) bool {
+	return p.HasRole(projectID + projectRoleSeparator + role)
+}
+
+// InGroup checks if the principal is a member of the given group
+func (p *Principal) InGroup(group string) bool {
+	return slices.Contains(p.Groups, group)
+}
+
+// Projects returns the sorted IDs of the projects the principal has a role in.
+// Project roles are realm roles named "<project UUID>_<role>".
+func (p *Principal) Projects() []string {
+	var projects []string
+	for _, role := range p.RealmRoles {
+		projectID, _, found := strings.Cut(role, projectRoleSeparator)
+		if !found {
+			continue
+		}
+		if _, err := uuid.Parse(projectID); err != nil {
+			continue
+		}
+		if !slices.Contains(projects, projectID) {
+			projects = append(projects, projectID)
+		}
+	}
+	slices.Sort(projects)
+	return projects
+}
+
+// IsM2M checks if the principal is a machine, i.e. the token was issued to a
+// Keycloak service account with the client credentials grant
+func (p *Principal) IsM2M() bool {
+	if strings.HasPrefix(p.Username, serviceAccountUsernamePrefix) {
+		return true
+	}
+	_, ok := p.Claims[clientIDClaim]
+	return ok
+}
+
+func stringSlice(value interface{}) []string {
+	switch vt := value.(type) {
+	case []string:
+		return vt
+	case []interface{}:
+		values := make([]string, 0, len(vt))
+		for _, v := range vt {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
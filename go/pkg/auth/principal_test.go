@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"gotest.tools/assert"
+	"testing"
+)
+
+const (
+	testProject1 = "0ad4f0ba-5a95-4ab1-a0a4-7a23e1c5a4f2"
+	testProject2 = "2b9e1c7e-6c42-4a3b-8d1f-3d1f0a6b7c8d"
+)
+
+func TestNewPrincipal(t *testing.T) {
+	claims := jwt.MapClaims{
+		"sub":                "4c1f0f3c-0b7e-4b64-9e2a-3bb8a5b2b1c3",
+		"preferred_username": "alice",
+		"email":              "alice@example.com",
+		"azp":                "webui-client",
+		"realm_access": map[string]interface{}{
+			"roles": []interface{}{
+				testProject2 + "_cat-rw",
+				testProject1 + "_cat-r",
+				testProject1 + "_alrt-r",
+				"default-roles-master",
+				"not-a-uuid_cat-r",
+			},
+		},
+		"resource_access": map[string]interface{}{
+			"account": map[string]interface{}{
+				"roles": []interface{}{"manage-account", "view-profile"},
+			},
+			"broken": "not an object",
+		},
+		"groups": []interface{}{"Edge-Manager-Group"},
+	}
+
+	p := NewPrincipal(claims)
+	assert.Equal(t, "4c1f0f3c-0b7e-4b64-9e2a-3bb8a5b2b1c3", p.Subject)
+	assert.Equal(t, "alice", p.Username)
+	assert.Equal(t, "alice@example.com", p.Email)
+	assert.Equal(t, "webui-client", p.AuthorizedParty)
+	assert.Assert(t, p.HasRole("default-roles-master"))
+	assert.Assert(t, !p.HasRole("admin"))
+	assert.Assert(t, p.HasClientRole("account", "view-profile"))
+	assert.Assert(t, !p.HasClientRole("account", "admin"))
+	assert.Assert(t, !p.HasClientRole("broken", "view-profile"))
+	assert.Assert(t, p.HasProjectRole(testProject1, "cat-r"))
+	assert.Assert(t, !p.HasProjectRole(testProject1, "cat-rw"))
+	assert.Assert(t, p.InGroup("Edge-Manager-Group"))
+	assert.DeepEqual(t, []string{testProject1, testProject2}, p.Projects())
+	assert.Assert(t, !p.IsM2M())
+}
+
+func TestPrincipal_IsM2M(t *testing.T) {
+	assert.Assert(t, NewPrincipal(jwt.MapClaims{"preferred_username": "service-account-edge-manager-m2m-client"}).IsM2M())
+	assert.Assert(t, NewPrincipal(jwt.MapClaims{"client_id": "edge-manager-m2m-client"}).IsM2M())
+
+	// A principal without any claims has no roles
+	p := NewPrincipal(jwt.MapClaims{})
+	assert.Assert(t, !p.IsM2M())
+	assert.Assert(t, !p.HasRole(""))
+	assert.Equal(t, 0, len(p.Projects()))
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	_, ok := PrincipalFromContext(context.Background())
+	assert.Assert(t, !ok)
+
+	ctx := NewContextWithClaims(context.Background(), jwt.MapClaims{"sub": "test"})
+	p, ok := PrincipalFromContext(ctx)
+	assert.Assert(t, ok)
+	assert.Equal(t, "test", p.Subject)
+	claims, ok := ClaimsFromContext(ctx)
+	assert.Assert(t, ok)
+	assert.Equal(t, "test", claims["sub"])
+}
@@ -0,0 +1,276 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	vault "github.com/hashicorp/vault/api"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	vaultKVDataPrefix     = "data"
+	vaultKVMetadataPrefix = "metadata"
+	vaultKVDeletePrefix   = "delete"
+	vaultKVCheckAndSet    = "check-and-set"
+	vaultMergePatchType   = "application/merge-patch+json"
+)
+
+// VaultKVMetadata is the metadata of a version of a KV secret
+type VaultKVMetadata struct {
+	CreatedTime    time.Time         `json:"created_time"`
+	DeletionTime   string            `json:"deletion_time"`
+	Destroyed      bool              `json:"destroyed"`
+	Version        int               `json:"version"`
+	CustomMetadata map[string]string `json:"custom_metadata"`
+}
+
+// VaultKVWriteOption configures a VaultKV Write or Patch
+type VaultKVWriteOption func(*vaultKVWriteOptions)
+
+type vaultKVWriteOptions struct {
+	cas *int
+}
+
+// WithCAS makes the write a check-and-set: it only succeeds if the current version of the secret
+// is the given version. Version 0 only succeeds if the secret does not exist.
+func WithCAS(version int) VaultKVWriteOption {
+	return func(o *vaultKVWriteOptions) {
+		o.cas = &version
+	}
+}
+
+// VaultKV is a client of a Vault KV version 2 secrets engine mount, authenticated with the
+// token of the VaultAuth. Secrets are read into and written from any JSON (un)marshallable value.
+// Errors are pkg/errors typed, e.g. NotFound if the secret does not exist and Conflict if a
+// check-and-set write fails.
+type VaultKV struct {
+	v     *vaultAuth
+	mount string
+}
+
+// KV returns a client of the KV version 2 mount; if mount is empty the one of WithVaultKVMount is used
+func (v *vaultAuth) KV(mount string) *VaultKV {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		mount = v.kvMount
+	}
+	return &VaultKV{v: v, mount: mount}
+}
+
+// ReadVaultKV reads the latest version of the secret at path as a T
+func ReadVaultKV[T any](ctx context.Context, kv *VaultKV, path string) (T, *VaultKVMetadata, error) {
+	var data T
+	metadata, err := kv.Read(ctx, path, &data)
+	return data, metadata, err
+}
+
+// Read reads the latest version of the secret at path into out
+func (kv *VaultKV) Read(ctx context.Context, path string, out interface{}) (*VaultKVMetadata, error) {
+	return kv.ReadVersion(ctx, path, 0, out)
+}
+
+// ReadVersion reads the given version of the secret at path into out; version 0 is the latest
+func (kv *VaultKV) ReadVersion(ctx context.Context, path string, version int, out interface{}) (*VaultKVMetadata, error) {
+	query := url.Values{}
+	if version > 0 {
+		query.Set("version", strconv.Itoa(version))
+	}
+	secret, err := kv.do(ctx, http.MethodGet, vaultKVDataPrefix, path, query, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil || secret.Data["data"] == nil {
+		return nil, errors.NewNotFound("vault secret %s/%s has no data", kv.mount, path)
+	}
+	if err := remarshal(secret.Data["data"], out); err != nil {
+		return nil, errors.NewInvalid("unable to decode vault secret %s/%s: %v", kv.mount, path, err)
+	}
+	return secretMetadata(secret.Data["metadata"])
+}
+
+// Write writes data as a new version of the secret at path
+func (kv *VaultKV) Write(ctx context.Context, path string, data interface{}, opts ...VaultKVWriteOption) (*VaultKVMetadata, error) {
+	return kv.write(ctx, http.MethodPost, "application/json", path, data, opts)
+}
+
+// Patch merges data into the latest version of the secret at path, following RFC 7396 JSON merge patch
+func (kv *VaultKV) Patch(ctx context.Context, path string, data interface{}, opts ...VaultKVWriteOption) (*VaultKVMetadata, error) {
+	return kv.write(ctx, http.MethodPatch, vaultMergePatchType, path, data, opts)
+}
+
+func (kv *VaultKV) write(ctx context.Context, method string, contentType string, path string, data interface{}, opts []VaultKVWriteOption) (*VaultKVMetadata, error) {
+	options := &vaultKVWriteOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	body := map[string]interface{}{"data": data}
+	if options.cas != nil {
+		body["options"] = map[string]interface{}{"cas": *options.cas}
+	}
+	secret, err := kv.do(ctx, method, vaultKVDataPrefix, path, nil, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return &VaultKVMetadata{}, nil
+	}
+	return secretMetadata(secret.Data)
+}
+
+// Delete soft deletes the latest version of the secret at path; older versions can still be read
+func (kv *VaultKV) Delete(ctx context.Context, path string) error {
+	_, err := kv.do(ctx, http.MethodDelete, vaultKVDataPrefix, path, nil, "", nil)
+	return err
+}
+
+// DeleteVersions soft deletes the given versions of the secret at path
+func (kv *VaultKV) DeleteVersions(ctx context.Context, path string, versions ...int) error {
+	_, err := kv.do(ctx, http.MethodPost, vaultKVDeletePrefix, path, nil, "application/json",
+		map[string]interface{}{"versions": versions})
+	return err
+}
+
+// DeleteAll permanently deletes all versions and the metadata of the secret at path
+func (kv *VaultKV) DeleteAll(ctx context.Context, path string) error {
+	_, err := kv.do(ctx, http.MethodDelete, vaultKVMetadataPrefix, path, nil, "", nil)
+	return err
+}
+
+// List returns the keys below path; keys ending with "/" are folders
+func (kv *VaultKV) List(ctx context.Context, path string) ([]string, error) {
+	secret, err := kv.do(ctx, http.MethodGet, vaultKVMetadataPrefix, path, url.Values{"list": []string{"true"}}, "", nil)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if secret != nil && secret.Data != nil {
+		if err := remarshal(secret.Data["keys"], &keys); err != nil {
+			return nil, errors.NewInvalid("unable to decode vault keys of %s/%s: %v", kv.mount, path, err)
+		}
+	}
+	return keys, nil
+}
+
+func (kv *VaultKV) do(ctx context.Context, method string, prefix string, path string, query url.Values, contentType string, body interface{}) (*vault.Secret, error) {
+	token, err := kv.v.GetVaultToken(ctx)
+	if err != nil {
+		// The logins return typed errors, e.g. Unauthorized if the credentials are rejected
+		// and Unavailable if Vault cannot be reached
+		var typed *errors.TypedError
+		if goerrors.As(err, &typed) {
+			return nil, err
+		}
+		return nil, errors.NewUnavailable("unable to log in to vault: %v", err)
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	vaultURL := kv.v.httpsVaultURL(fmt.Sprintf(vaultKVURL, kv.mount, prefix, strings.Join(segments, "/")))
+	if len(query) > 0 {
+		vaultURL += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		configBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.NewInvalid("unable to encode vault request: %v", err)
+		}
+		reqBody = bytes.NewReader(configBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, vaultURL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("X-Vault-Token", token)
+	if contentType != "" {
+		req.Header.Add("Content-Type", contentType)
+	}
+
+	resp, err := kv.v.httpClient.Do(req)
+	if err != nil {
+		return nil, vaultRequestError(ctx, "vault request", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	rawData, err := readAllFactory(resp.Body)
+	if err != nil {
+		return nil, errors.NewUnavailable("unable to read vault response: %v", err)
+	}
+	if err := vaultStatusError(resp.StatusCode, rawData, kv.mount+"/"+path); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNoContent || len(rawData) == 0 {
+		return nil, nil
+	}
+	secret, err := vault.ParseSecret(bytes.NewReader(rawData))
+	if err != nil {
+		return nil, errors.NewInvalid("unable to decode vault response: %v", err)
+	}
+	return secret, nil
+}
+
+// vaultStatusError maps the status of a Vault response to a typed error
+func vaultStatusError(statusCode int, body []byte, path string) error {
+	if statusCode >= 200 && statusCode < 300 {
+		return nil
+	}
+	var errResp struct {
+		Errors []string `json:"errors"`
+	}
+	_ = json.Unmarshal(body, &errResp)
+	message := strings.Join(errResp.Errors, "; ")
+
+	switch {
+	case statusCode == http.StatusNotFound:
+		return errors.NewNotFound("vault secret %s not found", path)
+	case statusCode == http.StatusBadRequest && strings.Contains(message, vaultKVCheckAndSet):
+		return errors.NewConflict("vault secret %s was modified: %s", path, message)
+	case statusCode == http.StatusBadRequest:
+		return errors.NewInvalid("vault request on %s is invalid: %s", path, message)
+	case statusCode == http.StatusUnauthorized:
+		return errors.NewUnauthorized("vault request on %s is unauthorized: %s", path, message)
+	case statusCode == http.StatusForbidden:
+		return errors.NewForbidden("vault request on %s is forbidden: %s", path, message)
+	case statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError:
+		return errors.NewUnavailable("vault request on %s failed %d: %s", path, statusCode, message)
+	}
+	return errors.NewUnknown("vault request on %s failed %d: %s", path, statusCode, message)
+}
+
+func secretMetadata(data interface{}) (*VaultKVMetadata, error) {
+	metadata := &VaultKVMetadata{}
+	if data == nil {
+		return metadata, nil
+	}
+	if err := remarshal(data, metadata); err != nil {
+		return nil, errors.NewInvalid("unable to decode vault secret metadata: %v", err)
+	}
+	return metadata, nil
+}
+
+// remarshal converts a generic JSON value into out
+func remarshal(in interface{}, out interface{}) error {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
@@ -0,0 +1,244 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"gotest.tools/assert"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testKVServer is a minimal Vault KV version 2 engine mounted at "kv"
+type testKVServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	secrets map[string][]map[string]interface{}
+	deleted map[string]map[int]bool
+}
+
+func newTestKVServer(t *testing.T) *testKVServer {
+	s := &testKVServer{
+		secrets: map[string][]map[string]interface{}{},
+		deleted: map[string]map[int]bool{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == vaultK8SLoginURL {
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"token"}}`))
+			return
+		}
+		assert.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		prefix, path, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/kv/"), "/")
+		versions := s.secrets[path]
+		switch {
+		case prefix == vaultKVDataPrefix && r.Method == http.MethodGet:
+			version := len(versions)
+			if v := r.URL.Query().Get("version"); v != "" {
+				version, _ = strconv.Atoi(v)
+			}
+			if version == 0 || version > len(versions) || s.deleted[path][version] {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[]}`))
+				return
+			}
+			writeKVResponse(w, map[string]interface{}{
+				"data":     versions[version-1],
+				"metadata": kvMetadata(version),
+			})
+		case prefix == vaultKVDataPrefix && (r.Method == http.MethodPost || r.Method == http.MethodPatch):
+			var body struct {
+				Data    map[string]interface{} `json:"data"`
+				Options struct {
+					CAS *int `json:"cas"`
+				} `json:"options"`
+			}
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+			if body.Options.CAS != nil && *body.Options.CAS != len(versions) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"]}`))
+				return
+			}
+			data := body.Data
+			if r.Method == http.MethodPatch {
+				assert.Equal(t, vaultMergePatchType, r.Header.Get("Content-Type"))
+				if len(versions) == 0 {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				data = map[string]interface{}{}
+				for k, v := range versions[len(versions)-1] {
+					data[k] = v
+				}
+				for k, v := range body.Data {
+					data[k] = v
+				}
+			}
+			s.secrets[path] = append(versions, data)
+			writeKVResponse(w, kvMetadata(len(versions)+1))
+		case prefix == vaultKVDataPrefix && r.Method == http.MethodDelete:
+			s.deleteVersions(path, len(versions))
+			w.WriteHeader(http.StatusNoContent)
+		case prefix == vaultKVDeletePrefix && r.Method == http.MethodPost:
+			var body struct {
+				Versions []int `json:"versions"`
+			}
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+			s.deleteVersions(path, body.Versions...)
+			w.WriteHeader(http.StatusNoContent)
+		case prefix == vaultKVMetadataPrefix && r.Method == http.MethodDelete:
+			delete(s.secrets, path)
+			delete(s.deleted, path)
+			w.WriteHeader(http.StatusNoContent)
+		case prefix == vaultKVMetadataPrefix && r.Method == http.MethodGet && r.URL.Query().Get("list") == "true":
+			if path != "" && !strings.HasSuffix(path, "/") {
+				path += "/"
+			}
+			keys := map[string]bool{}
+			for p := range s.secrets {
+				if rest, ok := strings.CutPrefix(p, path); ok && rest != "" {
+					if folder, _, isFolder := strings.Cut(rest, "/"); isFolder {
+						keys[folder+"/"] = true
+					} else {
+						keys[rest] = true
+					}
+				}
+			}
+			if len(keys) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			list := make([]string, 0, len(keys))
+			for k := range keys {
+				list = append(list, k)
+			}
+			sort.Strings(list)
+			writeKVResponse(w, map[string]interface{}{"keys": list})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return s
+}
+
+func (s *testKVServer) deleteVersions(path string, versions ...int) {
+	if s.deleted[path] == nil {
+		s.deleted[path] = map[int]bool{}
+	}
+	for _, v := range versions {
+		s.deleted[path][v] = true
+	}
+}
+
+func kvMetadata(version int) map[string]interface{} {
+	return map[string]interface{}{
+		"created_time":  time.Now().UTC().Format(time.RFC3339Nano),
+		"deletion_time": "",
+		"destroyed":     false,
+		"version":       version,
+	}
+}
+
+func writeKVResponse(w http.ResponseWriter, data interface{}) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+type testCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func TestVaultKV(t *testing.T) {
+	server := newTestKVServer(t)
+	defer server.Close()
+	v, err := NewVaultAuth(server.URL, server.URL, "test-svc",
+		WithServiceAccountTokenFile("testdata/k8stoken"), WithVaultKVMount("kv"))
+	assert.NilError(t, err)
+	kv := v.KV("")
+	ctx := context.Background()
+
+	_, _, err = ReadVaultKV[testCredentials](ctx, kv, "app/db")
+	assert.Assert(t, errors.IsNotFound(err))
+
+	metadata, err := kv.Write(ctx, "app/db", testCredentials{Username: "admin", Password: "p1"}, WithCAS(0))
+	assert.NilError(t, err)
+	assert.Equal(t, 1, metadata.Version)
+
+	// A check-and-set write against an outdated version is a conflict
+	_, err = kv.Write(ctx, "app/db", testCredentials{Username: "admin", Password: "p2"}, WithCAS(0))
+	assert.Assert(t, errors.IsConflict(err))
+
+	metadata, err = kv.Patch(ctx, "app/db", map[string]string{"password": "p2"}, WithCAS(1))
+	assert.NilError(t, err)
+	assert.Equal(t, 2, metadata.Version)
+
+	creds, metadata, err := ReadVaultKV[testCredentials](ctx, kv, "app/db")
+	assert.NilError(t, err)
+	assert.Equal(t, testCredentials{Username: "admin", Password: "p2"}, creds)
+	assert.Equal(t, 2, metadata.Version)
+	assert.Assert(t, !metadata.CreatedTime.IsZero())
+
+	var previous testCredentials
+	_, err = kv.ReadVersion(ctx, "app/db", 1, &previous)
+	assert.NilError(t, err)
+	assert.Equal(t, "p1", previous.Password)
+
+	_, err = kv.Write(ctx, "app/cache", map[string]string{"url": "redis://cache"})
+	assert.NilError(t, err)
+	_, err = kv.Write(ctx, "other", map[string]string{})
+	assert.NilError(t, err)
+	keys, err := kv.List(ctx, "app/")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"cache", "db"}, keys)
+	keys, err = kv.List(ctx, "")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"app/", "other"}, keys)
+	keys, err = kv.List(ctx, "missing/")
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(keys))
+
+	// Deleting the latest version leaves the older ones readable
+	assert.NilError(t, kv.Delete(ctx, "app/db"))
+	_, err = kv.Read(ctx, "app/db", &creds)
+	assert.Assert(t, errors.IsNotFound(err))
+	_, err = kv.ReadVersion(ctx, "app/db", 1, &previous)
+	assert.NilError(t, err)
+	assert.NilError(t, kv.DeleteVersions(ctx, "app/db", 1))
+	_, err = kv.ReadVersion(ctx, "app/db", 1, &previous)
+	assert.Assert(t, errors.IsNotFound(err))
+
+	assert.NilError(t, kv.DeleteAll(ctx, "app/cache"))
+	keys, err = kv.List(ctx, "app/")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"db"}, keys)
+}
+
+func TestVaultKV_ClientSecret(t *testing.T) {
+	server := newTestKVServer(t)
+	defer server.Close()
+	v, err := NewVaultAuth(server.URL, server.URL, "test-svc",
+		WithServiceAccountTokenFile("testdata/k8stoken"), WithVaultKVMount("kv"))
+	assert.NilError(t, err)
+	vault := v.(*vaultAuth)
+
+	_, err = vault.getClientSecretFromVault(context.Background())
+	assert.Assert(t, errors.IsNotFound(err))
+
+	_, err = v.KV("kv").Write(context.Background(), DefaultM2MSecretPath,
+		ClientSecretData{ClientID: "edge-manager-m2m-client", ClientSecret: "secret"})
+	assert.NilError(t, err)
+	secret, err := vault.getClientSecretFromVault(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, "secret", secret)
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// VaultTokenEnv is the environment variable conventionally holding a pre-issued Vault token
+	VaultTokenEnv = "VAULT_TOKEN"
+
+	vaultAppRoleLoginURL = `/v1/auth/approle/login`
+	vaultLookupSelfURL   = `/v1/auth/token/lookup-self`
+)
+
+// vaultLogin is a method to obtain a Vault token
+type vaultLogin struct {
+	login func(ctx context.Context, v *vaultAuth) (*vaultAuthResponse, error)
+	// revocable is false for tokens issued outside of the VaultAuth, which are left valid by Logout
+	revocable bool
+}
+
+var kubernetesLogin = vaultLogin{
+	login: func(ctx context.Context, v *vaultAuth) (*vaultAuthResponse, error) {
+		return v.kubernetesLogin(ctx)
+	},
+	revocable: true,
+}
+
+// WithKubernetesLogin logs in to Vault with the Kubernetes service account token and the role passed
+// to NewVaultAuth. This is the default; see WithServiceAccountTokenFile to read another token file.
+func WithKubernetesLogin() VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.login = kubernetesLogin
+	}
+}
+
+// WithAppRoleLogin logs in to Vault with the AppRole auth method, e.g. from CI jobs or VM based edge nodes
+func WithAppRoleLogin(roleID string, secretID string) VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.login = vaultLogin{
+			login: func(ctx context.Context, v *vaultAuth) (*vaultAuthResponse, error) {
+				return v.appRoleLogin(ctx, roleID, secretID)
+			},
+			revocable: true,
+		}
+	}
+}
+
+// WithTokenFromEnv uses the pre-issued Vault token of the given environment variable, e.g. VaultTokenEnv.
+// The token is renewed if it is renewable, but it is not revoked by Logout.
+func WithTokenFromEnv(name string) VaultAuthOption {
+	return withStaticToken(func() (string, error) {
+		token, ok := os.LookupEnv(name)
+		if !ok || token == "" {
+			return "", errors.NewInvalid("environment variable %s with the Vault token is not set", name)
+		}
+		return token, nil
+	})
+}
+
+// WithTokenFromFile uses the pre-issued Vault token of the given file, e.g. written by a Vault agent.
+// The file is read again on each login so that the token can be rotated. The token is renewed if
+// it is renewable, but it is not revoked by Logout.
+func WithTokenFromFile(path string) VaultAuthOption {
+	return withStaticToken(func() (string, error) {
+		tokenData, err := os.ReadFile(path)
+		if err != nil {
+			return "", errors.NewInvalid("unable to read the Vault token: %v", err)
+		}
+		return strings.TrimSpace(string(tokenData)), nil
+	})
+}
+
+func withStaticToken(readToken func() (string, error)) VaultAuthOption {
+	return func(v *vaultAuth) {
+		v.login = vaultLogin{
+			login: func(ctx context.Context, v *vaultAuth) (*vaultAuthResponse, error) {
+				token, err := readToken()
+				if err != nil {
+					return nil, err
+				}
+				return v.lookupToken(ctx, token)
+			},
+			revocable: false,
+		}
+	}
+}
+
+// appRoleLogin logs in to Vault with the AppRole role ID and secret ID
+func (v *vaultAuth) appRoleLogin(ctx context.Context, roleID string, secretID string) (*vaultAuthResponse, error) {
+	loginReq := struct {
+		RoleID   string `json:"role_id"`
+		SecretID string `json:"secret_id"`
+	}{
+		RoleID:   roleID,
+		SecretID: secretID,
+	}
+	body, _ := json.Marshal(loginReq)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		v.httpsVaultURL(vaultAppRoleLoginURL),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, vaultRequestError(ctx, "vault approle login request", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, vaultLoginStatusError("vault approle login request", resp.StatusCode)
+	}
+
+	loginResp := &vaultAuthResponse{}
+	err = json.NewDecoder(resp.Body).Decode(loginResp)
+	if err != nil {
+		return nil, errors.NewInternal("unable to decode vault approle login response: %v", err)
+	}
+	return loginResp, nil
+}
+
+// lookupToken validates a pre-issued token and reads its lease
+func (v *vaultAuth) lookupToken(ctx context.Context, token string) (*vaultAuthResponse, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		v.httpsVaultURL(vaultLookupSelfURL),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, vaultRequestError(ctx, "vault token lookup request", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, vaultLoginStatusError("vault token lookup request", resp.StatusCode)
+	}
+
+	var lookupResp struct {
+		Data struct {
+			TTL       int  `json:"ttl"`
+			Renewable bool `json:"renewable"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&lookupResp)
+	if err != nil {
+		return nil, errors.NewInternal("unable to decode vault token lookup response: %v", err)
+	}
+
+	loginResp := &vaultAuthResponse{}
+	loginResp.Auth.ClientToken = token
+	loginResp.Auth.LeaseDuration = lookupResp.Data.TTL
+	loginResp.Auth.Renewable = lookupResp.Data.Renewable
+	return loginResp, nil
+}
+
+// vaultRequestError maps a Vault login request that got no response to a typed error
+func vaultRequestError(ctx context.Context, op string, err error) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		return errors.NewCanceled("%s canceled", op)
+	case context.DeadlineExceeded:
+		return errors.NewTimeout("%s timed out", op)
+	}
+	return errors.NewUnavailable("%s failed: %v", op, err)
+}
+
+// vaultLoginStatusError maps the status of a failed Vault login response to a typed error.
+// Only a rejection of the credentials is Unauthorized, so that callers retry when Vault is down.
+func vaultLoginStatusError(op string, statusCode int) error {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return errors.NewUnauthorized("%s failed %d", op, statusCode)
+	case statusCode == http.StatusBadRequest:
+		return errors.NewInvalid("%s failed %d", op, statusCode)
+	case statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError:
+		return errors.NewUnavailable("%s failed %d", op, statusCode)
+	}
+	return errors.NewUnknown("%s failed %d", op, statusCode)
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"gotest.tools/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVaultLogin_AppRole(t *testing.T) {
+	server := newTestVaultServer(3600, true)
+	defer server.Close()
+
+	v, err := NewVaultAuth(server.URL, server.URL, "", WithAppRoleLogin("test-role", "test-secret"))
+	assert.NilError(t, err)
+	token, err := v.GetVaultToken(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, "approle-token-1", token)
+	assert.NilError(t, v.Logout(context.Background()))
+	assert.Equal(t, int32(1), server.revocations.Load())
+
+	v, err = NewVaultAuth(server.URL, server.URL, "", WithAppRoleLogin("test-role", "wrong-secret"))
+	assert.NilError(t, err)
+	_, err = v.GetVaultToken(context.Background())
+	assert.ErrorContains(t, err, "vault approle login request failed 400")
+}
+
+func TestVaultLogin_StaticToken(t *testing.T) {
+	server := newTestVaultServer(3600, true)
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NilError(t, os.WriteFile(tokenFile, []byte("static-token\n"), 0600))
+	t.Setenv("TEST_VAULT_TOKEN", "static-token")
+
+	for name, opt := range map[string]VaultAuthOption{
+		"env":  WithTokenFromEnv("TEST_VAULT_TOKEN"),
+		"file": WithTokenFromFile(tokenFile),
+	} {
+		t.Run(name, func(t *testing.T) {
+			v, err := NewVaultAuth(server.URL, server.URL, "", opt)
+			assert.NilError(t, err)
+			token, err := v.GetVaultToken(context.Background())
+			assert.NilError(t, err)
+			assert.Equal(t, "static-token", token)
+			status := v.LeaseStatus()
+			assert.Assert(t, status.Authenticated)
+			assert.Assert(t, status.Renewable)
+			assert.Assert(t, !status.Expires.IsZero())
+
+			// A pre-issued token is not revoked
+			assert.NilError(t, v.Logout(context.Background()))
+			assert.Assert(t, !v.LeaseStatus().Authenticated)
+		})
+	}
+	assert.Equal(t, int32(0), server.revocations.Load())
+	assert.Equal(t, int32(0), server.logins.Load())
+
+	v, err := NewVaultAuth(server.URL, server.URL, "", WithTokenFromEnv("TEST_VAULT_TOKEN_UNSET"))
+	assert.NilError(t, err)
+	_, err = v.GetVaultToken(context.Background())
+	assert.ErrorContains(t, err, "TEST_VAULT_TOKEN_UNSET")
+
+	t.Setenv("TEST_VAULT_TOKEN", "other-token")
+	v, err = NewVaultAuth(server.URL, server.URL, "", WithTokenFromEnv("TEST_VAULT_TOKEN"))
+	assert.NilError(t, err)
+	_, err = v.GetVaultToken(context.Background())
+	assert.ErrorContains(t, err, "vault token lookup request failed 403")
+}
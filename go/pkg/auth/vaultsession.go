@@ -0,0 +1,321 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"golang.org/x/sync/singleflight"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	vaultRenewSelfURL = `/v1/auth/token/renew-self` // #nosec
+
+	// vaultMinLease is the shortest lease worth renewing; a renewal granting less, e.g. because the
+	// token reached its maximum TTL, is followed by a new login
+	vaultMinLease = 10 * time.Second
+	// vaultExpiryMargin is how long before the lease expires a token is no longer handed out;
+	// it is capped at a third of the lease
+	vaultExpiryMargin = 5 * time.Second
+
+	vaultLoginKey = "login"
+)
+
+// vaultAuthResponse is the response of Vault login and token renewal requests
+type vaultAuthResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// VaultLeaseStatus reports the state of the Vault token lease for health checks
+type VaultLeaseStatus struct {
+	// Authenticated is true if a Vault token is held
+	Authenticated bool
+	// Renewable is true if the token lease can be renewed
+	Renewable bool
+	// LoginTime is the time of the last successful login
+	LoginTime time.Time
+	// LastRenewal is the time of the last successful renewal
+	LastRenewal time.Time
+	// Expires is when the lease expires, zero if the token does not expire
+	Expires time.Time
+	// NextRenewal is when the lease will next be renewed, zero if it is not renewed
+	NextRenewal time.Time
+	// LastError is the error of the last login or renewal, nil if it succeeded
+	LastError error
+}
+
+// vaultSession holds a Vault token obtained by a single login and renews its lease in the
+// background before it expires. If a renewal fails the session logs in again. The token is
+// only revoked by an explicit revoke.
+type vaultSession struct {
+	vaultServer string
+	httpClient  *http.Client
+	login       func(ctx context.Context) (*vaultAuthResponse, error)
+	revocable   bool
+	minLease    time.Duration
+	group       singleflight.Group
+
+	mu     sync.Mutex
+	token  string
+	lease  time.Duration
+	timer  *time.Timer
+	status VaultLeaseStatus
+	// generation is incremented whenever the token is forgotten, so that logins
+	// started before a revoke do not store their token
+	generation uint64
+}
+
+func newVaultSession(vaultServer string, httpClient *http.Client, login func(ctx context.Context) (*vaultAuthResponse, error), revocable bool) *vaultSession {
+	return &vaultSession{
+		vaultServer: vaultServer,
+		httpClient:  httpClient,
+		login:       login,
+		revocable:   revocable,
+		minLease:    vaultMinLease,
+	}
+}
+
+// getToken returns the Vault token, logging in if there is no valid one.
+// Concurrent callers share a single login.
+func (s *vaultSession) getToken(ctx context.Context) (string, error) {
+	if token, ok := s.validToken(); ok {
+		return token, nil
+	}
+	token, err, _ := s.group.Do(vaultLoginKey, func() (interface{}, error) {
+		if token, ok := s.validToken(); ok {
+			return token, nil
+		}
+		return s.doLogin(context.WithoutCancel(ctx), s.currentGeneration())
+	})
+	if err != nil {
+		return "", err
+	}
+	return token.(string), nil
+}
+
+func (s *vaultSession) validToken() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == "" {
+		return "", false
+	}
+	margin := vaultExpiryMargin
+	if margin > s.lease/3 {
+		margin = s.lease / 3
+	}
+	if !s.status.Expires.IsZero() && !time.Now().Before(s.status.Expires.Add(-margin)) {
+		return "", false
+	}
+	return s.token, true
+}
+
+func (s *vaultSession) currentGeneration() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation
+}
+
+// doLogin logs in and stores the token, unless the session was revoked since generation
+func (s *vaultSession) doLogin(ctx context.Context, generation uint64) (string, error) {
+	loginResp, err := s.login(ctx)
+	if err == nil && loginResp.Auth.ClientToken == "" {
+		err = errors.NewInternal("vault login response has no client token")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generation != generation {
+		// The token of the login is not stored, so it is never renewed and expires with its lease
+		return "", errors.NewCanceled("vault session revoked during login")
+	}
+	s.status.LastError = err
+	if err != nil {
+		return "", err
+	}
+	s.token = loginResp.Auth.ClientToken
+	s.status.Authenticated = true
+	s.status.LoginTime = time.Now()
+	s.status.LastRenewal = time.Time{}
+	s.updateLeaseLocked(loginResp)
+	return s.token, nil
+}
+
+// updateLeaseLocked records the lease of a login or renewal and schedules the next renewal
+// once two thirds of the lease have passed
+func (s *vaultSession) updateLeaseLocked(resp *vaultAuthResponse) {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.lease = time.Duration(resp.Auth.LeaseDuration) * time.Second
+	s.status.Renewable = resp.Auth.Renewable
+	s.status.Expires = time.Time{}
+	s.status.NextRenewal = time.Time{}
+	if s.lease <= 0 {
+		// The token does not expire
+		return
+	}
+	now := time.Now()
+	s.status.Expires = now.Add(s.lease)
+	renewIn := s.lease * 2 / 3
+	s.status.NextRenewal = now.Add(renewIn)
+	s.timer = time.AfterFunc(renewIn, s.renew)
+}
+
+// renew renews the lease of the token, or logs in again if it cannot be renewed
+func (s *vaultSession) renew() {
+	s.mu.Lock()
+	token := s.token
+	renewable := s.status.Renewable
+	generation := s.generation
+	s.mu.Unlock()
+	if token == "" {
+		return
+	}
+
+	ctx := context.Background()
+	if renewable {
+		resp, err := s.renewSelf(ctx, token)
+		if err == nil && time.Duration(resp.Auth.LeaseDuration)*time.Second >= s.minLease {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.token != token {
+				// The token was revoked or replaced meanwhile
+				return
+			}
+			s.status.LastError = nil
+			s.status.LastRenewal = time.Now()
+			s.updateLeaseLocked(resp)
+			return
+		}
+		if err != nil {
+			log.Warnf("unable to renew Vault token, logging in again: %v", err)
+		}
+	}
+
+	s.mu.Lock()
+	replaced := s.token != token
+	s.mu.Unlock()
+	if replaced {
+		// The token was revoked or replaced while it was being renewed
+		return
+	}
+	_, err, _ := s.group.Do(vaultLoginKey, func() (interface{}, error) {
+		return s.doLogin(ctx, generation)
+	})
+	if err != nil {
+		log.Warnf("unable to log in to Vault: %v", err)
+		s.retryLogin()
+	}
+}
+
+// retryLogin schedules a new login attempt while the current token may still be valid
+func (s *vaultSession) retryLogin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == "" || s.timer != nil && s.status.NextRenewal.After(time.Now()) {
+		return
+	}
+	retryIn := vaultMinLease
+	if !s.status.Expires.IsZero() && time.Until(s.status.Expires) <= retryIn {
+		// Let the next caller log in once the token has expired
+		return
+	}
+	s.status.NextRenewal = time.Now().Add(retryIn)
+	s.timer = time.AfterFunc(retryIn, s.renew)
+}
+
+func (s *vaultSession) renewSelf(ctx context.Context, token string) (*vaultAuthResponse, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		s.vaultServer+vaultRenewSelfURL,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault token renewal failed %d", resp.StatusCode)
+	}
+	renewResp := &vaultAuthResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(renewResp); err != nil {
+		return nil, err
+	}
+	return renewResp, nil
+}
+
+// revoke revokes the token and stops renewing it. A later getToken logs in again.
+// Tokens that are not revocable are only forgotten.
+func (s *vaultSession) revoke(ctx context.Context) error {
+	// The token is forgotten before Vault is called, so that renewals and logins in progress
+	// do not store a new token, and Vault is called without holding the lock, so that getToken
+	// callers are not blocked by it
+	s.mu.Lock()
+	token := s.token
+	s.forgetLocked()
+	s.mu.Unlock()
+	if token == "" || !s.revocable {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		s.vaultServer+vaultRevokeSelfURL,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusNoContent {
+		log.Infof("http error on revoke: %d", resp.StatusCode)
+		return fmt.Errorf("http error on revoke: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *vaultSession) forgetLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.token = ""
+	s.generation++
+	s.status = VaultLeaseStatus{LoginTime: s.status.LoginTime, LastRenewal: s.status.LastRenewal}
+}
+
+func (s *vaultSession) leaseStatus() VaultLeaseStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gotest.tools/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// testVaultServer is a Vault server issuing numbered tokens with the Kubernetes login
+type testVaultServer struct {
+	*httptest.Server
+	logins       atomic.Int32
+	renewals     atomic.Int32
+	revocations  atomic.Int32
+	lease        int
+	renewable    bool
+	failRenewals atomic.Bool
+}
+
+func newTestVaultServer(lease int, renewable bool) *testVaultServer {
+	s := &testVaultServer{lease: lease, renewable: renewable}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := vaultAuthResponse{}
+		resp.Auth.LeaseDuration = s.lease
+		resp.Auth.Renewable = s.renewable
+		switch r.URL.Path {
+		case vaultK8SLoginURL:
+			resp.Auth.ClientToken = fmt.Sprintf("token-%d", s.logins.Add(1))
+		case vaultAppRoleLoginURL:
+			var loginReq map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&loginReq)
+			if loginReq["role_id"] != "test-role" || loginReq["secret_id"] != "test-secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			resp.Auth.ClientToken = fmt.Sprintf("approle-token-%d", s.logins.Add(1))
+		case vaultLookupSelfURL:
+			if r.Header.Get("X-Vault-Token") != "static-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"ttl": s.lease, "renewable": s.renewable},
+			})
+			return
+		case vaultRenewSelfURL:
+			s.renewals.Add(1)
+			if s.failRenewals.Load() {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			resp.Auth.ClientToken = r.Header.Get("X-Vault-Token")
+		case vaultRevokeSelfURL:
+			s.revocations.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	return s
+}
+
+func newTestVaultAuth(t *testing.T, server *testVaultServer) *vaultAuth {
+	v, err := NewVaultAuth(server.URL, server.URL, "test-svc", WithServiceAccountTokenFile("testdata/k8stoken"))
+	assert.NilError(t, err)
+	vault := v.(*vaultAuth)
+	vault.session.minLease = 0
+	return vault
+}
+
+func TestVaultSession_SingleLogin(t *testing.T) {
+	server := newTestVaultServer(3600, true)
+	defer server.Close()
+	v := newTestVaultAuth(t, server)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := v.GetVaultToken(context.Background())
+			assert.NilError(t, err)
+			assert.Equal(t, "token-1", token)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), server.logins.Load())
+
+	status := v.LeaseStatus()
+	assert.Assert(t, status.Authenticated)
+	assert.Assert(t, status.Renewable)
+	assert.Assert(t, !status.Expires.IsZero())
+	assert.Assert(t, status.NextRenewal.Before(status.Expires))
+	assert.NilError(t, status.LastError)
+
+	// The token is only revoked on logout, after which the next call logs in again
+	assert.NilError(t, v.Logout(context.Background()))
+	assert.Equal(t, int32(1), server.revocations.Load())
+	assert.Assert(t, !v.LeaseStatus().Authenticated)
+	token, err := v.GetVaultToken(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, "token-2", token)
+	assert.NilError(t, v.Logout(context.Background()))
+}
+
+func TestVaultSession_Renewal(t *testing.T) {
+	server := newTestVaultServer(1, true)
+	defer server.Close()
+	v := newTestVaultAuth(t, server)
+	defer func() { _ = v.Logout(context.Background()) }()
+
+	token, err := v.GetVaultToken(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	assert.Assert(t, waitFor(func() bool { return server.renewals.Load() > 0 }))
+	assert.Assert(t, waitFor(func() bool { return !v.LeaseStatus().LastRenewal.IsZero() }))
+	token, err = v.GetVaultToken(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, "token-1", token)
+	assert.Equal(t, int32(1), server.logins.Load())
+
+	// A failed renewal is followed by a new login
+	server.failRenewals.Store(true)
+	assert.Assert(t, waitFor(func() bool { return server.logins.Load() > 1 }))
+	assert.Assert(t, waitFor(func() bool { return v.LeaseStatus().LoginTime.After(v.LeaseStatus().LastRenewal) }))
+	token, err = v.GetVaultToken(context.Background())
+	assert.NilError(t, err)
+	assert.Assert(t, token != "token-1")
+}
+
+func TestVaultSession_NotRenewable(t *testing.T) {
+	server := newTestVaultServer(1, false)
+	defer server.Close()
+	v := newTestVaultAuth(t, server)
+	defer func() { _ = v.Logout(context.Background()) }()
+
+	_, err := v.GetVaultToken(context.Background())
+	assert.NilError(t, err)
+
+	// A token that cannot be renewed is replaced by a new login before it expires
+	assert.Assert(t, waitFor(func() bool { return server.logins.Load() > 1 }))
+	assert.Equal(t, int32(0), server.renewals.Load())
+}
+
+func TestVaultSession_LoginFailure(t *testing.T) {
+	server := newTestVaultServer(3600, true)
+	defer server.Close()
+	v, err := NewVaultAuth(server.URL, server.URL, "test-svc", WithServiceAccountTokenFile("testdata/missing"))
+	assert.NilError(t, err)
+
+	_, err = v.GetVaultToken(context.Background())
+	assert.Assert(t, err != nil)
+	status := v.LeaseStatus()
+	assert.Assert(t, !status.Authenticated)
+	assert.Assert(t, status.LastError != nil)
+	assert.Equal(t, int32(0), server.logins.Load())
+}
+
+func TestVaultSession_RevokeDuringRenewal(t *testing.T) {
+	renewing := make(chan struct{})
+	release := make(chan struct{})
+	var revocations atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case vaultRenewSelfURL:
+			close(renewing)
+			<-release
+			w.WriteHeader(http.StatusForbidden)
+		case vaultRevokeSelfURL:
+			revocations.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var logins atomic.Int32
+	s := newVaultSession(server.URL, server.Client(), func(context.Context) (*vaultAuthResponse, error) {
+		resp := &vaultAuthResponse{}
+		resp.Auth.ClientToken = fmt.Sprintf("token-%d", logins.Add(1))
+		resp.Auth.LeaseDuration = 3600
+		resp.Auth.Renewable = true
+		return resp, nil
+	}, true)
+	token, err := s.getToken(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// The renewal fails once the session is revoked, and must not log in again
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.renew()
+	}()
+	<-renewing
+	assert.NilError(t, s.revoke(context.Background()))
+	close(release)
+	<-done
+
+	assert.Equal(t, int32(1), revocations.Load())
+	assert.Equal(t, int32(1), logins.Load())
+	assert.Assert(t, !s.leaseStatus().Authenticated)
+	s.mu.Lock()
+	assert.Equal(t, "", s.token)
+	assert.Assert(t, s.timer == nil)
+	s.mu.Unlock()
+
+	// A login started before the revoke does not store its token
+	generation := s.currentGeneration()
+	assert.NilError(t, s.revoke(context.Background()))
+	_, err = s.doLogin(context.Background(), generation)
+	assert.ErrorContains(t, err, "revoked during login")
+	assert.Assert(t, !s.leaseStatus().Authenticated)
+}
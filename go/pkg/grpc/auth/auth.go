@@ -6,13 +6,13 @@ package auth
 import (
 	"context"
 	"fmt"
-	"github.com/golang-jwt/jwt/v5"
 	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
 	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
 	"github.com/open-edge-platform/orch-library/go/dazl"
 	"github.com/open-edge-platform/orch-library/go/pkg/auth"
 	"os"
 	"strings"
+	"sync"
 )
 
 var log = dazl.GetLogger()
@@ -27,69 +27,55 @@ const (
 	allowMissingAuthClients       = "ALLOW_MISSING_AUTH_CLIENTS"
 )
 
+var allowMissingAuthDeprecation sync.Once
+
 // AuthenticationInterceptor an interceptor for authentication
 // If there is an environment variable ALLOW_MISSING_AUTH_CLIENTS (and there is no
 // Authentication Token attached to request) then look through its values and see
 // the current requests client matches anything.
 // ALLOW_MISSING_AUTH_CLIENTS is acomma separated list of client names
+//
+// ALLOW_MISSING_AUTH_CLIENTS is deprecated: it trusts the "client" metadata sent by the caller,
+// which any caller can spoof. Use ExemptMethods to skip authentication for specific methods instead.
 func AuthenticationInterceptor(ctx context.Context) (context.Context, error) {
-	niceMd := metautils.ExtractIncoming(ctx)
+	return newChainAuthFunc(true, BearerTokenAuthenticator(new(auth.JwtAuthenticator)))(ctx)
+}
 
-	// Extract token from metadata in the context
-	tokenString1, err1 := grpc_auth.AuthFromMD(ctx, ContextMetadataBearerKeyLower)
-	tokenString2, err2 := grpc_auth.AuthFromMD(ctx, ContextMetadataBearerKeyCamel)
-	if err1 != nil && err2 != nil {
-		// failed to extract JWT token - checking if bypass is allowed
-		acceptNoAuth := os.Getenv(allowMissingAuthClients)
-		allowedMissingClients := strings.Split(acceptNoAuth, ",")
-		requestClient := niceMd.Get(ContextMetadataClientKeyLower)
-		if requestClient == "" {
-			// failed to extract client
-			requestClient = niceMd.Get(ContextMetadataClientKeyCamel)
-		}
-		var foundMissingAuthClient bool
-		for _, amc := range allowedMissingClients {
-			if requestClient == strings.TrimSpace(strings.ToLower(amc)) {
-				foundMissingAuthClient = true
-				break
-			}
-		}
-		if foundMissingAuthClient && err1.Error() == `rpc error: code = Unauthenticated desc = Request unauthenticated with bearer` {
-			log.Warnf("Allowing unauthenticated gRPC request from client: %s", requestClient)
-			return ctx, nil
-		}
-		return nil, err1
-	}
+// NewAuthenticationInterceptor creates an interceptor for authentication that validates the
+// bearer token with the given authenticator, e.g. an auth.JwtAuthenticator or an
+// auth.IntrospectionAuthenticator. Unlike AuthenticationInterceptor it ignores ALLOW_MISSING_AUTH_CLIENTS.
+func NewAuthenticationInterceptor(authenticator auth.Authenticator) grpc_auth.AuthFunc {
+	return NewChainAuthenticationInterceptor(BearerTokenAuthenticator(authenticator))
+}
 
-	var tokenString string
-	if err1 == nil {
-		tokenString = tokenString1
-	}
-	if err2 == nil {
-		tokenString = tokenString2
+// allowMissingAuth checks if the client of a request without credentials is listed in ALLOW_MISSING_AUTH_CLIENTS
+func allowMissingAuth(ctx context.Context) bool {
+	acceptNoAuth := os.Getenv(allowMissingAuthClients)
+	if acceptNoAuth == "" {
+		return false
 	}
+	allowMissingAuthDeprecation.Do(func() {
+		log.Warnf("%s is deprecated, the client metadata can be spoofed by any caller; "+
+			"exempt methods from authentication instead", allowMissingAuthClients)
+	})
 
-	// Authenticate the jwt token
-	jwtAuth := new(auth.JwtAuthenticator)
-	authClaimsIf, err := jwtAuth.ParseAndValidate(tokenString)
-	if err != nil {
-		return ctx, err
+	niceMd := metautils.ExtractIncoming(ctx)
+	allowedMissingClients := strings.Split(acceptNoAuth, ",")
+	requestClient := niceMd.Get(ContextMetadataClientKeyLower)
+	if requestClient == "" {
+		// failed to extract client
+		requestClient = niceMd.Get(ContextMetadataClientKeyCamel)
 	}
-
-	authClaims, isMap := authClaimsIf.(jwt.MapClaims)
-	if !isMap {
-		return nil, fmt.Errorf("error converting claims to a map")
+	if requestClient == "" {
+		return false
 	}
-	for k, v := range authClaims {
-		err = HandleClaim(&niceMd, []string{k}, v)
-		if err != nil {
-			return nil, err
+	for _, amc := range allowedMissingClients {
+		if requestClient == strings.TrimSpace(strings.ToLower(amc)) {
+			log.Warnf("Allowing unauthenticated gRPC request from client: %s", requestClient)
+			return true
 		}
 	}
-
-	log.Debugf("JWT token is valid, proceeding with processing")
-
-	return niceMd.ToIncoming(ctx), nil
+	return false
 }
 
 // HandleClaim function converts claims extracted from JWT to the string and appends them to the context
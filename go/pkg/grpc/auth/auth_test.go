@@ -10,7 +10,9 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
 	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"gotest.tools/assert"
 	"os"
 	"strings"
@@ -241,3 +243,134 @@ func Test_HandleClaims(t *testing.T) {
 	assert.DeepEqual(t, []string{"testRole1", "testRole2"}, md.Get("realm-access/roles"))
 	assert.DeepEqual(t, []string{"testRole1", "testRole2"}, md.Get("resource-access/account/roles"))
 }
+
+type testAuthenticator struct {
+	tokens map[string]jwt.MapClaims
+}
+
+func (a *testAuthenticator) Authenticate(token string) (jwt.MapClaims, error) {
+	claims, ok := a.tokens[token]
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "token is not active")
+	}
+	return claims, nil
+}
+
+func Test_NewAuthenticationInterceptor(t *testing.T) {
+	authenticator := &testAuthenticator{tokens: map[string]jwt.MapClaims{
+		"opaque-token": {
+			"sub":          "test",
+			"email":        "test1@opennetworking.org",
+			"realm_access": map[string]interface{}{"roles": []interface{}{"testRole1", "testRole2"}},
+		},
+	}}
+	interceptor := NewAuthenticationInterceptor(authenticator)
+
+	mdIn := metadata.Pairs("authorization", "Bearer opaque-token")
+	intercepted, err := interceptor(metadata.NewIncomingContext(context.Background(), mdIn))
+	assert.NilError(t, err)
+	md, ok := metadata.FromIncomingContext(intercepted)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, []string{"test1@opennetworking.org"}, md.Get("email"))
+	assert.DeepEqual(t, []string{"testRole1", "testRole2"}, md.Get("realm_access/roles"))
+
+	mdIn = metadata.Pairs("authorization", "Bearer revoked-token")
+	_, err = interceptor(metadata.NewIncomingContext(context.Background(), mdIn))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func Test_AuthenticationInterceptor_NoAuth_NoClient(t *testing.T) {
+	oldValue := os.Getenv(allowMissingAuthClients)
+	assert.NilError(t, os.Unsetenv(allowMissingAuthClients))
+	defer func() {
+		assert.NilError(t, os.Setenv(allowMissingAuthClients, oldValue))
+	}()
+
+	// Neither an unset ALLOW_MISSING_AUTH_CLIENTS nor a missing client metadata allows the request
+	_, err := AuthenticationInterceptor(context.Background())
+	assert.ErrorContains(t, err, "Request unauthenticated with bearer")
+
+	assert.NilError(t, os.Setenv(allowMissingAuthClients, "test-client,"))
+	_, err = AuthenticationInterceptor(context.Background())
+	assert.ErrorContains(t, err, "Request unauthenticated with bearer")
+}
+
+func Test_AuthenticationInterceptor_SpoofedClaims(t *testing.T) {
+	authenticator := &testAuthenticator{tokens: map[string]jwt.MapClaims{
+		"user-token": {
+			"sub":          "user",
+			"email":        "user@example.com",
+			"realm_access": map[string]interface{}{"roles": []interface{}{"reader"}},
+			"tenant":       map[string]interface{}{"id": "t1"},
+		},
+	}}
+	interceptor := NewAuthenticationInterceptor(authenticator)
+
+	mdIn := metadata.Pairs("authorization", "Bearer user-token",
+		"email", "admin@example.com",
+		"realm_access/roles", "admin",
+		"tenant/id", "t2",
+		"tenant/admin", "true",
+		"x-tenant/name", "acme",
+		"x-request-id", "abc")
+	ctx := metadata.NewIncomingContext(context.Background(), mdIn)
+	intercepted, err := interceptor(ctx)
+	assert.NilError(t, err)
+
+	md, ok := metadata.FromIncomingContext(intercepted)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, []string{"user@example.com"}, md.Get("email"))
+	assert.DeepEqual(t, []string{"reader"}, md.Get("realm_access/roles"))
+	assert.DeepEqual(t, []string{"t1"}, md.Get("tenant/id"))
+	assert.DeepEqual(t, []string(nil), md.Get("tenant/admin"))
+	assert.DeepEqual(t, []string{"acme"}, md.Get("x-tenant/name"))
+	assert.DeepEqual(t, []string{"abc"}, md.Get("x-request-id"))
+
+	// The metadata of the original context is left untouched
+	assert.DeepEqual(t, []string{"admin"}, mdIn.Get("realm_access/roles"))
+
+	claims, ok := auth.ClaimsFromContext(intercepted)
+	assert.Assert(t, ok)
+	assert.Equal(t, "user@example.com", claims["email"])
+	principal, ok := auth.PrincipalFromContext(intercepted)
+	assert.Assert(t, ok)
+	assert.Assert(t, principal.HasRole("reader"))
+	assert.Assert(t, !principal.HasRole("admin"))
+	_, ok = auth.ClaimsFromContext(ctx)
+	assert.Assert(t, !ok)
+}
+
+func Test_AuthenticationInterceptor_SpoofedMissingClaims(t *testing.T) {
+	// Like an API key or client certificate, the token has no roles or groups
+	authenticator := &testAuthenticator{tokens: map[string]jwt.MapClaims{
+		"key-token": {"sub": "service", "email": "service@example.com"},
+	}}
+	interceptor := ExemptMethods(NewAuthenticationInterceptor(authenticator), HealthCheckMethods)
+	mdIn := metadata.Pairs("authorization", "Bearer key-token",
+		"realm_access/roles", "admin",
+		"resource_access/catalog/roles", "admin",
+		"groups", "admins",
+		"preferred_username", "admin",
+		"name", "catalog",
+		"x-tenant/name", "acme",
+		"x-request-id", "abc")
+
+	for _, method := range []string{"/catalog.v1.CatalogService/ListPublishers", "/grpc.health.v1.Health/Check"} {
+		t.Run(method, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(methodContext(method), mdIn)
+			intercepted, err := interceptor(ctx)
+			assert.NilError(t, err)
+
+			md, ok := metadata.FromIncomingContext(intercepted)
+			assert.Assert(t, ok)
+			assert.DeepEqual(t, []string(nil), md.Get("realm_access/roles"))
+			assert.DeepEqual(t, []string(nil), md.Get("resource_access/catalog/roles"))
+			assert.DeepEqual(t, []string(nil), md.Get("groups"))
+			assert.DeepEqual(t, []string(nil), md.Get("preferred_username"))
+			// Application metadata that is not a claim of the principal is kept
+			assert.DeepEqual(t, []string{"catalog"}, md.Get("name"))
+			assert.DeepEqual(t, []string{"acme"}, md.Get("x-tenant/name"))
+			assert.DeepEqual(t, []string{"abc"}, md.Get("x-request-id"))
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"github.com/open-edge-platform/orch-library/go/pkg/openpolicyagent"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// OpaInputMethodKey is the key of the gRPC full method name in the OPA input
+	OpaInputMethodKey = "method"
+	// OpaInputRequestKey is the key of the request message in the OPA input
+	OpaInputRequestKey = "request"
+	// OpaInputMetadataKey is the key of the incoming metadata (including the JWT claims) in the OPA input
+	OpaInputMetadataKey = "metadata"
+	// OpaInputClaimsKey is the key of the verified claims of the caller in the OPA input.
+	// Unlike the metadata the claims cannot be supplied by the caller; it is absent for unauthenticated requests.
+	OpaInputClaimsKey = "claims"
+
+	contextMetadataAuthorizationKey = "authorization"
+)
+
+// RuleResolver maps a gRPC full method name (e.g. /package.Service/Method) to the Rego rule to query
+type RuleResolver func(fullMethod string) string
+
+// AuthorizerOption configures an OpaAuthorizer
+type AuthorizerOption func(*OpaAuthorizer)
+
+// WithRuleResolver sets a function used to choose the Rego rule per gRPC method.
+// When the resolver returns an empty string the default rule is used.
+func WithRuleResolver(resolver RuleResolver) AuthorizerOption {
+	return func(a *OpaAuthorizer) {
+		a.ruleResolver = resolver
+	}
+}
+
+// OpaAuthorizer authorizes gRPC requests by querying an Open Policy Agent rule
+type OpaAuthorizer struct {
+	evaluator    openpolicyagent.Evaluator
+	pkg          string
+	rule         string
+	ruleResolver RuleResolver
+}
+
+// NewOpaAuthorizer creates an authorizer that queries the given package and rule with the evaluator,
+// e.g. an openpolicyagent.PolicyClient or an openpolicyagent.ServerEvaluator wrapping the generated client
+func NewOpaAuthorizer(evaluator openpolicyagent.Evaluator, pkg string, rule string, opts ...AuthorizerOption) *OpaAuthorizer {
+	a := &OpaAuthorizer{
+		evaluator: evaluator,
+		pkg:       pkg,
+		rule:      rule,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// UnaryServerInterceptor returns a unary interceptor that authorizes each request
+func (a *OpaAuthorizer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.Authorize(ctx, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a stream interceptor that authorizes each stream when it is opened.
+// The request message is not known at that point, so only the method and metadata are sent to OPA.
+func (a *OpaAuthorizer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.Authorize(stream.Context(), info.FullMethod, nil); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// Authorize queries OPA for the given method and request, returning a PermissionDenied error on a deny
+func (a *OpaAuthorizer) Authorize(ctx context.Context, fullMethod string, req interface{}) error {
+	input, err := a.buildInput(ctx, fullMethod, req)
+	if err != nil {
+		return status.Errorf(codes.Internal, "unable to build authorization input: %v", err)
+	}
+
+	rule := a.rule
+	if a.ruleResolver != nil {
+		if r := a.ruleResolver(fullMethod); r != "" {
+			rule = r
+		}
+	}
+
+	decision, err := a.evaluator.Evaluate(ctx, a.pkg, rule, input)
+	if err != nil {
+		log.Warnf("OPA query %s/%s failed for %s: %v", a.pkg, rule, fullMethod, err)
+		if errors.IsUnavailable(err) || errors.IsTimeout(err) {
+			return status.Errorf(codes.Unavailable, "unable to reach authorization service")
+		}
+		return status.Errorf(codes.Internal, "authorization failed")
+	}
+
+	// An undefined rule or a non-boolean result is treated as a deny
+	allowed := decision.Allowed()
+	if !allowed {
+		log.Debugf("Access denied by OPA %s/%s for %s", a.pkg, rule, fullMethod)
+		return status.Errorf(codes.PermissionDenied, "access denied to %s", fullMethod)
+	}
+	return nil
+}
+
+func (a *OpaAuthorizer) buildInput(ctx context.Context, fullMethod string, req interface{}) (openpolicyagent.OpaInput, error) {
+	input := map[string]interface{}{
+		OpaInputMethodKey: fullMethod,
+	}
+
+	md := metadata.MD{}
+	if incoming, ok := metadata.FromIncomingContext(ctx); ok {
+		md = incoming.Copy()
+	}
+	// The bearer token has already been verified and its claims copied
+	// into the metadata, so there is no need to hand it to OPA
+	delete(md, contextMetadataAuthorizationKey)
+	input[OpaInputMetadataKey] = map[string][]string(md)
+	if claims, ok := auth.ClaimsFromContext(ctx); ok {
+		input[OpaInputClaimsKey] = map[string]interface{}(claims)
+	}
+
+	if req != nil {
+		request, err := requestToMap(req)
+		if err != nil {
+			return openpolicyagent.OpaInput{}, err
+		}
+		input[OpaInputRequestKey] = request
+	}
+
+	return openpolicyagent.OpaInput{Input: input}, nil
+}
+
+// requestToMap converts a request message into a generic map so that field
+// names in the policy match the JSON representation of the message
+func requestToMap(req interface{}) (map[string]interface{}, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if msg, ok := req.(proto.Message); ok {
+		raw, err = protojson.Marshal(msg)
+	} else {
+		raw, err = json.Marshal(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	request := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/openpolicyagent"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+const testFullMethod = "/catalog.v1.CatalogService/CreatePublisher"
+
+type testRequest struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+func opaResponse(t *testing.T, statusCode int, result interface{}) *openpolicyagent.PostV1DataPackageRuleResponse {
+	resp := &openpolicyagent.PostV1DataPackageRuleResponse{
+		HTTPResponse: &http.Response{StatusCode: statusCode, Status: fmt.Sprintf("%d", statusCode)},
+	}
+	if statusCode != http.StatusOK {
+		return resp
+	}
+	resp.JSON200 = &openpolicyagent.OpaResponse{}
+	switch r := result.(type) {
+	case bool:
+		assert.NilError(t, resp.JSON200.Result.FromOpaResponseResult1(r))
+	case map[string]interface{}:
+		assert.NilError(t, resp.JSON200.Result.FromOpaResponseResult0(r))
+	}
+	return resp
+}
+
+func Test_OpaAuthorizer_Allow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	opaClient := openpolicyagent.NewMockClientWithResponsesInterface(ctrl)
+
+	md := metadata.Pairs("authorization", "bearer abc", "email", "test1@opennetworking.org",
+		"realm_access/roles", "role1", "realm_access/roles", "role2")
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	ctx = auth.NewContextWithClaims(ctx, jwt.MapClaims{"email": "test1@opennetworking.org"})
+
+	opaClient.EXPECT().PostV1DataPackageRuleWithResponse(gomock.Any(), "catalog", "allow", nil, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ string, _ *openpolicyagent.PostV1DataPackageRuleParams,
+			body openpolicyagent.OpaInput, _ ...openpolicyagent.RequestEditorFn) (*openpolicyagent.PostV1DataPackageRuleResponse, error) {
+			assert.Equal(t, testFullMethod, body.Input[OpaInputMethodKey])
+			request, ok := body.Input[OpaInputRequestKey].(map[string]interface{})
+			assert.Assert(t, ok)
+			assert.Equal(t, "pub1", request["name"])
+			inputMd, ok := body.Input[OpaInputMetadataKey].(map[string][]string)
+			assert.Assert(t, ok)
+			assert.DeepEqual(t, []string{"role1", "role2"}, inputMd["realm_access/roles"])
+			_, hasToken := inputMd["authorization"]
+			assert.Assert(t, !hasToken, "bearer token should not be passed to OPA")
+			claims, ok := body.Input[OpaInputClaimsKey].(map[string]interface{})
+			assert.Assert(t, ok)
+			assert.Equal(t, "test1@opennetworking.org", claims["email"])
+			return opaResponse(t, http.StatusOK, true), nil
+		})
+
+	authorizer := NewOpaAuthorizer(openpolicyagent.NewServerEvaluator(opaClient), "catalog", "allow")
+	called := false
+	resp, err := authorizer.UnaryServerInterceptor()(ctx, &testRequest{Name: "pub1"},
+		&grpc.UnaryServerInfo{FullMethod: testFullMethod},
+		func(_ context.Context, _ interface{}) (interface{}, error) {
+			called = true
+			return "ok", nil
+		})
+	assert.NilError(t, err)
+	assert.Assert(t, called)
+	assert.Equal(t, "ok", resp)
+}
+
+func Test_OpaAuthorizer_Deny(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	opaClient := openpolicyagent.NewMockClientWithResponsesInterface(ctrl)
+
+	testCases := []struct {
+		name     string
+		response *openpolicyagent.PostV1DataPackageRuleResponse
+		err      error
+		code     codes.Code
+	}{
+		{
+			name:     "deny",
+			response: opaResponse(t, http.StatusOK, false),
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "undefined result",
+			response: opaResponse(t, http.StatusOK, nil),
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "non boolean result",
+			response: opaResponse(t, http.StatusOK, map[string]interface{}{"allow": true}),
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "OPA error",
+			response: opaResponse(t, http.StatusInternalServerError, nil),
+			code:     codes.Internal,
+		},
+		{
+			name: "OPA unreachable",
+			err:  fmt.Errorf("connection refused"),
+			code: codes.Unavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opaClient.EXPECT().PostV1DataPackageRuleWithResponse(gomock.Any(), "catalog", "allow", nil, gomock.Any()).
+				Return(tc.response, tc.err)
+
+			authorizer := NewOpaAuthorizer(openpolicyagent.NewServerEvaluator(opaClient), "catalog", "allow")
+			_, err := authorizer.UnaryServerInterceptor()(context.Background(), &testRequest{Name: "pub1"},
+				&grpc.UnaryServerInfo{FullMethod: testFullMethod},
+				func(_ context.Context, _ interface{}) (interface{}, error) {
+					t.Fatal("handler should not be called")
+					return nil, nil
+				})
+			assert.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func Test_OpaAuthorizer_Stream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	opaClient := openpolicyagent.NewMockClientWithResponsesInterface(ctrl)
+
+	opaClient.EXPECT().PostV1DataPackageRuleWithResponse(gomock.Any(), "catalog", "WatchPublishers", nil, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ string, _ *openpolicyagent.PostV1DataPackageRuleParams,
+			body openpolicyagent.OpaInput, _ ...openpolicyagent.RequestEditorFn) (*openpolicyagent.PostV1DataPackageRuleResponse, error) {
+			_, hasRequest := body.Input[OpaInputRequestKey]
+			assert.Assert(t, !hasRequest)
+			_, hasClaims := body.Input[OpaInputClaimsKey]
+			assert.Assert(t, !hasClaims)
+			return opaResponse(t, http.StatusOK, false), nil
+		})
+
+	authorizer := NewOpaAuthorizer(openpolicyagent.NewServerEvaluator(opaClient), "catalog", "allow", WithRuleResolver(func(fullMethod string) string {
+		return "WatchPublishers"
+	}))
+	err := authorizer.StreamServerInterceptor()(nil, &testServerStream{ctx: context.Background()},
+		&grpc.StreamServerInfo{FullMethod: "/catalog.v1.CatalogService/WatchPublishers"},
+		func(_ interface{}, _ grpc.ServerStream) error {
+			t.Fatal("handler should not be called")
+			return nil
+		})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
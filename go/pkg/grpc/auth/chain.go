@@ -0,0 +1,234 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"slices"
+	"strings"
+)
+
+// ErrNoCredentials is returned by a RequestAuthenticator when the request does not carry
+// the kind of credentials it authenticates, so that the next authenticator is tried
+var ErrNoCredentials = errors.New("no credentials")
+
+// RequestAuthenticator authenticates a gRPC request from its incoming context
+type RequestAuthenticator interface {
+	// Scheme names the kind of credentials that are authenticated, e.g. "bearer"
+	Scheme() string
+	// AuthenticateRequest returns the claims of the caller, or ErrNoCredentials if the
+	// request carries no credentials of this kind
+	AuthenticateRequest(ctx context.Context) (jwt.MapClaims, error)
+}
+
+type bearerTokenAuthenticator struct {
+	authenticator auth.Authenticator
+}
+
+// BearerTokenAuthenticator authenticates the bearer token of the "authorization" metadata
+// with the given authenticator, e.g. an auth.JwtAuthenticator
+func BearerTokenAuthenticator(authenticator auth.Authenticator) RequestAuthenticator {
+	return &bearerTokenAuthenticator{authenticator: authenticator}
+}
+
+func (a *bearerTokenAuthenticator) Scheme() string {
+	return ContextMetadataBearerKeyLower
+}
+
+func (a *bearerTokenAuthenticator) AuthenticateRequest(ctx context.Context) (jwt.MapClaims, error) {
+	// Extract token from metadata in the context
+	tokenString1, err1 := grpc_auth.AuthFromMD(ctx, ContextMetadataBearerKeyLower)
+	tokenString2, err2 := grpc_auth.AuthFromMD(ctx, ContextMetadataBearerKeyCamel)
+	if err1 != nil && err2 != nil {
+		return nil, ErrNoCredentials
+	}
+
+	var tokenString string
+	if err1 == nil {
+		tokenString = tokenString1
+	}
+	if err2 == nil {
+		tokenString = tokenString2
+	}
+	return a.authenticator.Authenticate(tokenString)
+}
+
+type apiKeyAuthenticator struct {
+	key           string
+	authenticator auth.Authenticator
+}
+
+// APIKeyAuthenticator authenticates the API key carried in the given metadata key,
+// e.g. "x-api-key", with the given authenticator, e.g. an auth.APIKeyAuthenticator
+func APIKeyAuthenticator(metadataKey string, authenticator auth.Authenticator) RequestAuthenticator {
+	return &apiKeyAuthenticator{key: strings.ToLower(metadataKey), authenticator: authenticator}
+}
+
+func (a *apiKeyAuthenticator) Scheme() string {
+	return a.key
+}
+
+func (a *apiKeyAuthenticator) AuthenticateRequest(ctx context.Context) (jwt.MapClaims, error) {
+	key := metautils.ExtractIncoming(ctx).Get(a.key)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	return a.authenticator.Authenticate(key)
+}
+
+// ClientCertClaimsFunc maps a verified client certificate to the claims of the caller
+type ClientCertClaimsFunc func(cert *x509.Certificate) (jwt.MapClaims, error)
+
+type clientCertAuthenticator struct {
+	claimsFunc ClientCertClaimsFunc
+}
+
+// ClientCertAuthenticator authenticates the TLS client certificate of the peer. Only certificates
+// verified against the server client CAs are accepted. If claimsFunc is nil the claims are the
+// certificate subject common name as "sub" and its first e-mail address as "email".
+func ClientCertAuthenticator(claimsFunc ClientCertClaimsFunc) RequestAuthenticator {
+	if claimsFunc == nil {
+		claimsFunc = defaultClientCertClaims
+	}
+	return &clientCertAuthenticator{claimsFunc: claimsFunc}
+}
+
+func (a *clientCertAuthenticator) Scheme() string {
+	return "client certificate"
+}
+
+func (a *clientCertAuthenticator) AuthenticateRequest(ctx context.Context) (jwt.MapClaims, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	// A certificate that was requested but not verified, see tls.RequestClientCert, is no identity
+	if len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+	return a.claimsFunc(tlsInfo.State.VerifiedChains[0][0])
+}
+
+func defaultClientCertClaims(cert *x509.Certificate) (jwt.MapClaims, error) {
+	if cert.Subject.CommonName == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "client certificate has no common name")
+	}
+	claims := jwt.MapClaims{"sub": cert.Subject.CommonName}
+	if len(cert.EmailAddresses) > 0 {
+		claims["email"] = cert.EmailAddresses[0]
+	}
+	return claims, nil
+}
+
+// NewChainAuthenticationInterceptor creates an interceptor for authentication that tries the
+// authenticators in order, e.g. client certificate, API key and then bearer token. The first
+// authenticator finding its credentials in the request decides; if those credentials are
+// invalid the request is rejected without trying the others. The claims of the caller are
+// added to the incoming metadata.
+func NewChainAuthenticationInterceptor(authenticators ...RequestAuthenticator) grpc_auth.AuthFunc {
+	return newChainAuthFunc(false, authenticators...)
+}
+
+// newChainAuthFunc creates the authentication function of NewChainAuthenticationInterceptor;
+// requests without credentials are checked against ALLOW_MISSING_AUTH_CLIENTS if allowMissing is set
+func newChainAuthFunc(allowMissing bool, authenticators ...RequestAuthenticator) grpc_auth.AuthFunc {
+	schemes := make([]string, 0, len(authenticators))
+	for _, a := range authenticators {
+		schemes = append(schemes, a.Scheme())
+	}
+	noCredentials := status.Errorf(codes.Unauthenticated, "Request unauthenticated with %s", strings.Join(schemes, " or "))
+
+	return func(ctx context.Context) (context.Context, error) {
+		for _, authenticator := range authenticators {
+			authClaims, err := authenticator.AuthenticateRequest(ctx)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			if err != nil {
+				return ctx, err
+			}
+			return claimsToIncoming(ctx, authClaims)
+		}
+
+		if allowMissing && allowMissingAuth(ctx) {
+			return stripClaimMetadata(ctx, nil).ToIncoming(ctx), nil
+		}
+		return nil, noCredentials
+	}
+}
+
+// principalClaims are the claims an auth.Principal is built from. Their metadata keys, and the keys of
+// their nested claims, are never accepted from the caller, even if the verified credentials lack them.
+var principalClaims = []string{
+	"sub", auth.PreferredUsernameClaim, auth.EmailClaim, "client_id", auth.GroupsClaim,
+	auth.RealmAccessClaim, auth.ResourceAccessClaim,
+}
+
+// stripClaimMetadata removes the incoming metadata keys of the principal claims and of the given
+// verified claims, so that a caller cannot forge a claim that the verified credentials do not have,
+// e.g. on exempt methods or with an API key whose claims have no roles. Other application metadata,
+// e.g. "x-tenant/name", is kept.
+func stripClaimMetadata(ctx context.Context, authClaims jwt.MapClaims) metautils.NiceMD {
+	claims := slices.Clone(principalClaims)
+	for k := range authClaims {
+		claims = append(claims, k)
+	}
+	niceMd := metautils.ExtractIncoming(ctx).Clone()
+	for k := range niceMd {
+		if isClaimMetadata(k, claims) {
+			log.Warnf("Removing client supplied metadata %s that could be mistaken for a verified claim", k)
+			delete(niceMd, k)
+		}
+	}
+	return niceMd
+}
+
+// isClaimMetadata returns true if the metadata key is one of the claims or one of their nested claims
+func isClaimMetadata(key string, claims []string) bool {
+	for _, claim := range claims {
+		// Metadata keys are lower case
+		claim = strings.ToLower(claim)
+		if key == claim || strings.HasPrefix(key, claim+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// claimsToIncoming stores the claims in the context and writes them to the incoming metadata.
+// Metadata keys sent by the caller that carry a principal claim, or that collide with a verified
+// claim, are removed, so that the caller cannot add values to, or forge, a verified claim.
+func claimsToIncoming(ctx context.Context, authClaims jwt.MapClaims) (context.Context, error) {
+	claimsMd := metautils.NiceMD(metadata.MD{})
+	for k, v := range authClaims {
+		err := HandleClaim(&claimsMd, []string{k}, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	niceMd := stripClaimMetadata(ctx, authClaims)
+	for k, v := range claimsMd {
+		niceMd[k] = v
+	}
+
+	log.Debugf("Token is valid, proceeding with processing")
+
+	return niceMd.ToIncoming(auth.NewContextWithClaims(ctx, authClaims)), nil
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+func tlsPeerContext(ctx context.Context, cert *x509.Certificate) context.Context {
+	state := tls.ConnectionState{}
+	if cert != nil {
+		state.PeerCertificates = []*x509.Certificate{cert}
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+}
+
+func Test_ChainAuthenticationInterceptor(t *testing.T) {
+	tokens := &testAuthenticator{tokens: map[string]jwt.MapClaims{
+		"user-token": {"sub": "user", "email": "user@example.com"},
+	}}
+	apiKeys := auth.NewAPIKeyAuthenticator(map[string]jwt.MapClaims{
+		"machine-key": {"sub": "machine"},
+	})
+	interceptor := NewChainAuthenticationInterceptor(
+		ClientCertAuthenticator(nil),
+		APIKeyAuthenticator("X-API-Key", apiKeys),
+		BearerTokenAuthenticator(tokens),
+	)
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "edge-node-1"},
+		EmailAddresses: []string{"node@example.com"},
+	}
+
+	testCases := []struct {
+		name  string
+		ctx   context.Context
+		sub   string
+		email string
+		code  codes.Code
+	}{
+		{
+			name:  "client certificate",
+			ctx:   tlsPeerContext(metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer user-token")), cert),
+			sub:   "edge-node-1",
+			email: "node@example.com",
+		},
+		{
+			name: "API key",
+			ctx:  tlsPeerContext(metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "machine-key")), nil),
+			sub:  "machine",
+		},
+		{
+			name:  "bearer token",
+			ctx:   metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer user-token")),
+			sub:   "user",
+			email: "user@example.com",
+		},
+		{
+			name: "invalid API key is not retried with the bearer token",
+			ctx: metadata.NewIncomingContext(context.Background(),
+				metadata.Pairs("x-api-key", "wrong-key", "authorization", "Bearer user-token")),
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "no credentials",
+			ctx:  metadata.NewIncomingContext(context.Background(), metadata.Pairs("client", "test-client")),
+			code: codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			intercepted, err := interceptor(tc.ctx)
+			if tc.code != codes.OK {
+				assert.Equal(t, tc.code, status.Code(err))
+				return
+			}
+			assert.NilError(t, err)
+			md, ok := metadata.FromIncomingContext(intercepted)
+			assert.Assert(t, ok)
+			assert.Equal(t, tc.sub, md.Get("sub")[0])
+			if tc.email != "" {
+				assert.Equal(t, tc.email, md.Get("email")[0])
+			}
+		})
+	}
+}
+
+func Test_ChainAuthenticationInterceptor_NoCredentials(t *testing.T) {
+	oldValue := os.Getenv(allowMissingAuthClients)
+	assert.NilError(t, os.Setenv(allowMissingAuthClients, "test-client"))
+	defer func() {
+		assert.NilError(t, os.Setenv(allowMissingAuthClients, oldValue))
+	}()
+
+	// ALLOW_MISSING_AUTH_CLIENTS is only honored by the legacy AuthenticationInterceptor
+	interceptor := NewChainAuthenticationInterceptor(
+		ClientCertAuthenticator(nil),
+		BearerTokenAuthenticator(&testAuthenticator{}),
+	)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client", "test-client"))
+	_, err := interceptor(ctx)
+	assert.ErrorContains(t, err, "Request unauthenticated with client certificate or bearer")
+}
@@ -0,0 +1,230 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"github.com/open-edge-platform/orch-library/go/pkg/openidconnect"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenRefreshMargin is how long before its expiry a cached token is replaced;
+// it is capped at half the lifetime of the token
+const DefaultTokenRefreshMargin = 30 * time.Second
+
+// Token is an access token attached to outgoing calls
+type Token struct {
+	AccessToken string
+	// Expiry is when the token expires; a zero Expiry means it is unknown and the token is not cached
+	Expiry time.Time
+}
+
+// TokenSource provides the access tokens attached to outgoing calls
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token calls f
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// StaticTokenSource always returns the same token, which never expires
+func StaticTokenSource(accessToken string) TokenSource {
+	token := &Token{AccessToken: accessToken, Expiry: time.Now().AddDate(100, 0, 0)}
+	return TokenSourceFunc(func(_ context.Context) (*Token, error) {
+		return token, nil
+	})
+}
+
+// M2MTokenSource returns the M2M tokens of VaultAuth.GetM2MToken.
+// The expiry is read from the "exp" claim of the token without verifying it.
+func M2MTokenSource(vaultAuth auth.VaultAuth) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		accessToken, err := vaultAuth.GetM2MToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if accessToken == "" {
+			return nil, errors.NewUnauthorized("no M2M token available, is USE_M2M_TOKEN set?")
+		}
+		return &Token{AccessToken: accessToken, Expiry: auth.UnverifiedExpiry(accessToken)}, nil
+	})
+}
+
+// ClientCredentialsTokenSource requests tokens with the OAuth 2.0 client credentials grant from
+// the token endpoint of the client, authenticating with the client ID and secret
+func ClientCredentialsTokenSource(client openidconnect.ClientWithResponsesInterface, clientID string, clientSecret string, scopes ...string) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (*Token, error) {
+		grantType := openidconnect.ClientCredentials
+		body := openidconnect.Token{GrantType: &grantType}
+		if len(scopes) > 0 {
+			scope := strings.Join(scopes, " ")
+			body.Scope = &scope
+		}
+		now := time.Now()
+		resp, err := client.PostProtocolOpenidConnectTokenWithFormdataBodyWithResponse(ctx, body,
+			func(_ context.Context, req *http.Request) error {
+				req.SetBasicAuth(clientID, clientSecret)
+				return nil
+			})
+		if err != nil {
+			return nil, errors.NewUnavailable("token request failed: %v", err)
+		}
+		if resp.JSON200 == nil || resp.JSON200.AccessToken == nil {
+			msg := fmt.Sprintf("token request of %s failed %d", clientID, resp.StatusCode())
+			if resp.JSONDefault != nil && resp.JSONDefault.Error != nil {
+				msg = fmt.Sprintf("%s: %s", msg, *resp.JSONDefault.Error)
+			}
+			if resp.StatusCode() == http.StatusTooManyRequests || resp.StatusCode() >= http.StatusInternalServerError {
+				return nil, errors.NewUnavailable("%s", msg)
+			}
+			return nil, errors.NewUnauthorized("%s", msg)
+		}
+		token := &Token{AccessToken: *resp.JSON200.AccessToken}
+		if resp.JSON200.ExpiresIn != nil {
+			token.Expiry = now.Add(time.Duration(*resp.JSON200.ExpiresIn) * time.Second)
+		} else {
+			token.Expiry = auth.UnverifiedExpiry(token.AccessToken)
+		}
+		return token, nil
+	})
+}
+
+// PerRPCCredentialsOption configures the credentials created by NewPerRPCCredentials
+type PerRPCCredentialsOption func(*perRPCCredentials)
+
+// WithInsecureTransport allows sending tokens over connections without transport security.
+// Only use it for tests or connections that are secured otherwise, e.g. by a service mesh.
+func WithInsecureTransport() PerRPCCredentialsOption {
+	return func(c *perRPCCredentials) {
+		c.allowInsecure = true
+	}
+}
+
+// WithTokenRefreshMargin sets how long before its expiry a cached token is replaced; it is capped at
+// half the lifetime of the token, so that short-lived tokens are still cached
+func WithTokenRefreshMargin(margin time.Duration) PerRPCCredentialsOption {
+	return func(c *perRPCCredentials) {
+		c.refreshMargin = margin
+	}
+}
+
+type perRPCCredentials struct {
+	source        TokenSource
+	allowInsecure bool
+	refreshMargin time.Duration
+	group         singleflight.Group
+
+	mu    sync.Mutex
+	token *Token
+	// staleAt is when the cached token is replaced
+	staleAt time.Time
+}
+
+// NewPerRPCCredentials creates credentials attaching a bearer token of the source to every call,
+// for use with grpc.WithPerRPCCredentials. Tokens are cached until shortly before they expire and
+// concurrent calls share one token request. Unless WithInsecureTransport is given, the credentials
+// require transport security and are not sent over insecure connections.
+func NewPerRPCCredentials(source TokenSource, opts ...PerRPCCredentialsOption) credentials.PerRPCCredentials {
+	c := &perRPCCredentials{
+		source:        source,
+		refreshMargin: DefaultTokenRefreshMargin,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// GetRequestMetadata returns the "authorization" metadata of a call
+func (c *perRPCCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	if !c.allowInsecure {
+		ri, _ := credentials.RequestInfoFromContext(ctx)
+		if err := credentials.CheckSecurityLevel(ri.AuthInfo, credentials.PrivacyAndIntegrity); err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "refusing to send token over an insecure connection: %v", err)
+		}
+	}
+	accessToken, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, tokenStatusError(err)
+	}
+	return map[string]string{"authorization": "Bearer " + accessToken}, nil
+}
+
+// tokenStatusError converts an error of the token source to a status error, so that unavailable
+// token endpoints fail calls with a retryable code
+func tokenStatusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var typed *errors.TypedError
+	if goerrors.As(err, &typed) {
+		return status.Error(errors.Status(typed).Code(), err.Error())
+	}
+	return status.Errorf(codes.Unauthenticated, "unable to obtain access token: %v", err)
+}
+
+// RequireTransportSecurity reports whether the credentials require transport security
+func (c *perRPCCredentials) RequireTransportSecurity() bool {
+	return !c.allowInsecure
+}
+
+func (c *perRPCCredentials) cached() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == nil || !time.Now().Before(c.staleAt) {
+		return "", false
+	}
+	return c.token.AccessToken, true
+}
+
+func (c *perRPCCredentials) accessToken(ctx context.Context) (string, error) {
+	if accessToken, ok := c.cached(); ok {
+		return accessToken, nil
+	}
+	// The request is shared with other calls, so it must not be canceled with this call
+	accessToken, err, _ := c.group.Do("token", func() (interface{}, error) {
+		if accessToken, ok := c.cached(); ok {
+			return accessToken, nil
+		}
+		obtained := time.Now()
+		token, err := c.source.Token(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		lifetime := token.Expiry.Sub(obtained)
+		if token.Expiry.IsZero() || lifetime <= 0 {
+			c.token = nil
+		} else {
+			margin := c.refreshMargin
+			if margin > lifetime/2 {
+				margin = lifetime / 2
+			}
+			c.token = token
+			c.staleAt = token.Expiry.Add(-margin)
+		}
+		c.mu.Unlock()
+		return token.AccessToken, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return accessToken.(string), nil
+}
@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth/mocks"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"github.com/open-edge-platform/orch-library/go/pkg/openidconnect"
+	"github.com/open-edge-platform/orch-library/go/pkg/openidconnect/oidctest"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gotest.tools/assert"
+)
+
+func countingTokenSource(calls *int32, expiry time.Duration) TokenSource {
+	return TokenSourceFunc(func(_ context.Context) (*Token, error) {
+		n := atomic.AddInt32(calls, 1)
+		token := &Token{AccessToken: fmt.Sprintf("token-%d", n)}
+		if expiry != 0 {
+			token.Expiry = time.Now().Add(expiry)
+		}
+		return token, nil
+	})
+}
+
+func Test_PerRPCCredentials_Cache(t *testing.T) {
+	var calls int32
+	creds := NewPerRPCCredentials(countingTokenSource(&calls, time.Hour), WithInsecureTransport())
+	assert.Assert(t, !creds.RequireTransportSecurity())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			md, err := creds.GetRequestMetadata(context.Background())
+			assert.NilError(t, err)
+			assert.Equal(t, "Bearer token-1", md["authorization"])
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// The refresh margin is capped at half the lifetime, so short-lived tokens are still reused
+	calls = 0
+	creds = NewPerRPCCredentials(countingTokenSource(&calls, 200*time.Millisecond), WithInsecureTransport())
+	for _, want := range []string{"Bearer token-1", "Bearer token-1"} {
+		md, err := creds.GetRequestMetadata(context.Background())
+		assert.NilError(t, err)
+		assert.Equal(t, want, md["authorization"])
+	}
+	time.Sleep(110 * time.Millisecond)
+	md, err := creds.GetRequestMetadata(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, "Bearer token-2", md["authorization"])
+
+	// Expired tokens, or tokens without an expiry, are not reused
+	calls = 0
+	creds = NewPerRPCCredentials(countingTokenSource(&calls, -time.Minute), WithInsecureTransport())
+	for i := 1; i <= 2; i++ {
+		md, err := creds.GetRequestMetadata(context.Background())
+		assert.NilError(t, err)
+		assert.Equal(t, fmt.Sprintf("Bearer token-%d", i), md["authorization"])
+	}
+	calls = 0
+	creds = NewPerRPCCredentials(countingTokenSource(&calls, 0), WithInsecureTransport())
+	for i := 0; i < 2; i++ {
+		_, err := creds.GetRequestMetadata(context.Background())
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, int32(2), calls)
+}
+
+func Test_PerRPCCredentials_Errors(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "unavailable", err: errors.NewUnavailable("keycloak is down"), code: codes.Unavailable},
+		{name: "wrapped unavailable", err: fmt.Errorf("m2m token: %w", errors.NewUnavailable("keycloak is down")), code: codes.Unavailable},
+		{name: "unauthorized", err: errors.NewUnauthorized("bad client secret"), code: codes.Unauthenticated},
+		{name: "untyped", err: fmt.Errorf("no token"), code: codes.Unauthenticated},
+		{name: "status", err: status.Error(codes.ResourceExhausted, "slow down"), code: codes.ResourceExhausted},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			creds := NewPerRPCCredentials(TokenSourceFunc(func(_ context.Context) (*Token, error) {
+				return nil, tc.err
+			}), WithInsecureTransport())
+			_, err := creds.GetRequestMetadata(context.Background())
+			assert.Equal(t, tc.code, status.Code(err))
+		})
+	}
+
+	// Without transport security information the token is not sent
+	creds := NewPerRPCCredentials(StaticTokenSource("abc"))
+	assert.Assert(t, creds.RequireTransportSecurity())
+	_, err := creds.GetRequestMetadata(context.Background())
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func Test_ClientCredentialsTokenSource(t *testing.T) {
+	p := oidctest.NewProvider(oidctest.WithClient("app-m2m", "secret", nil))
+	defer p.Close()
+	client, err := openidconnect.NewClientWithResponses(p.Issuer())
+	assert.NilError(t, err)
+
+	token, err := ClientCredentialsTokenSource(client, "app-m2m", "secret", "openid").Token(context.Background())
+	assert.NilError(t, err)
+	assert.Assert(t, time.Until(token.Expiry) > 4*time.Minute)
+	claims, err := auth.NewJwtAuthenticator(auth.WithIssuer(p.Issuer())).Authenticate(token.AccessToken)
+	assert.NilError(t, err)
+	assert.Equal(t, "service-account-app-m2m", claims["sub"])
+	assert.Equal(t, "openid", claims["scope"])
+
+	_, err = ClientCredentialsTokenSource(client, "app-m2m", "wrong").Token(context.Background())
+	assert.Assert(t, errors.IsUnauthorized(err))
+	assert.ErrorContains(t, err, "invalid_client")
+}
+
+func Test_M2MTokenSource(t *testing.T) {
+	p := oidctest.NewProvider()
+	defer p.Close()
+	accessToken := p.IssueToken(jwt.MapClaims{"sub": "service-account-app-m2m"}, oidctest.WithLifetime(time.Hour))
+
+	vaultAuth := &mocks.VaultAuth{}
+	vaultAuth.On("GetM2MToken", mock.Anything).Return(accessToken, nil).Once()
+	token, err := M2MTokenSource(vaultAuth).Token(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, accessToken, token.AccessToken)
+	assert.Assert(t, time.Until(token.Expiry) > 59*time.Minute)
+
+	vaultAuth.On("GetM2MToken", mock.Anything).Return("", nil).Once()
+	_, err = M2MTokenSource(vaultAuth).Token(context.Background())
+	assert.Assert(t, errors.IsUnauthorized(err))
+	vaultAuth.AssertExpectations(t)
+}
+
+func Test_PerRPCCredentials_Call(t *testing.T) {
+	p := oidctest.NewProvider(oidctest.WithClient("app-m2m", "secret", nil))
+	defer p.Close()
+	client, err := openidconnect.NewClientWithResponses(p.Issuer())
+	assert.NilError(t, err)
+	source := ClientCredentialsTokenSource(client, "app-m2m", "secret")
+
+	listener := bufconn.Listen(1024 * 1024)
+	authFunc := NewAuthenticationInterceptor(auth.NewJwtAuthenticator(auth.WithIssuer(p.Issuer())))
+	server := grpc.NewServer(grpc.UnaryInterceptor(grpc_auth.UnaryServerInterceptor(authFunc)))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+	dialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})
+
+	// Insecure connections are refused unless explicitly allowed
+	_, err = grpc.NewClient("passthrough:///bufnet", dialer,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(NewPerRPCCredentials(source)))
+	assert.ErrorContains(t, err, "transport")
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialer,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(NewPerRPCCredentials(source, WithInsecureTransport())))
+	assert.NilError(t, err)
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NilError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	conn, err = grpc.NewClient("passthrough:///bufnet", dialer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NilError(t, err)
+	defer conn.Close()
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
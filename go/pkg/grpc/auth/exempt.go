@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	"google.golang.org/grpc"
+	"strings"
+)
+
+const (
+	// HealthCheckMethods matches the methods of the standard gRPC health checking service
+	HealthCheckMethods = "/grpc.health.v1.Health/*"
+	// ReflectionMethods matches the methods of all versions of the gRPC server reflection service
+	ReflectionMethods = "/grpc.reflection.*"
+)
+
+// ExemptMethods wraps an authentication function so that requests to the given methods are
+// not authenticated. A method is either a full gRPC method name, e.g. "/catalog.v1.CatalogService/ListPublishers",
+// or a prefix ending with "*", e.g. "/grpc.health.v1.Health/*". Exempt requests carry no claims;
+// metadata sent by the caller that could be mistaken for a claim is removed.
+func ExemptMethods(authFunc grpc_auth.AuthFunc, methods ...string) grpc_auth.AuthFunc {
+	if len(methods) == 0 {
+		return authFunc
+	}
+	exempt := newMethodMatcher(methods)
+
+	return func(ctx context.Context) (context.Context, error) {
+		method, ok := grpc.Method(ctx)
+		if ok && exempt.match(method) {
+			log.Debugf("Method %s is exempt from authentication", method)
+			return stripClaimMetadata(ctx, nil).ToIncoming(ctx), nil
+		}
+		return authFunc(ctx)
+	}
+}
+
+// ExemptUnaryServerInterceptor wraps a unary interceptor, e.g. of an OpaAuthorizer, so that it is
+// skipped for the given methods. Methods have the same form as in ExemptMethods.
+func ExemptUnaryServerInterceptor(interceptor grpc.UnaryServerInterceptor, methods ...string) grpc.UnaryServerInterceptor {
+	if len(methods) == 0 {
+		return interceptor
+	}
+	exempt := newMethodMatcher(methods)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if exempt.match(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		return interceptor(ctx, req, info, handler)
+	}
+}
+
+// ExemptStreamServerInterceptor wraps a stream interceptor, e.g. of an OpaAuthorizer, so that it is
+// skipped for the given methods. Methods have the same form as in ExemptMethods.
+func ExemptStreamServerInterceptor(interceptor grpc.StreamServerInterceptor, methods ...string) grpc.StreamServerInterceptor {
+	if len(methods) == 0 {
+		return interceptor
+	}
+	exempt := newMethodMatcher(methods)
+
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if exempt.match(info.FullMethod) {
+			return handler(srv, stream)
+		}
+		return interceptor(srv, stream, info, handler)
+	}
+}
+
+// methodMatcher matches full gRPC method names exactly or by a prefix ending with "*"
+type methodMatcher struct {
+	exact    map[string]struct{}
+	prefixes []string
+}
+
+func newMethodMatcher(methods []string) *methodMatcher {
+	m := &methodMatcher{exact: make(map[string]struct{}, len(methods))}
+	for _, method := range methods {
+		if prefix, ok := strings.CutSuffix(method, "*"); ok {
+			m.prefixes = append(m.prefixes, prefix)
+		} else {
+			m.exact[method] = struct{}{}
+		}
+	}
+	return m
+}
+
+func (m *methodMatcher) match(method string) bool {
+	if _, ok := m.exact[method]; ok {
+		return true
+	}
+	for _, prefix := range m.prefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+type testTransportStream struct {
+	method string
+}
+
+func (s *testTransportStream) Method() string                 { return s.method }
+func (s *testTransportStream) SetHeader(_ metadata.MD) error  { return nil }
+func (s *testTransportStream) SendHeader(_ metadata.MD) error { return nil }
+func (s *testTransportStream) SetTrailer(_ metadata.MD) error { return nil }
+
+func methodContext(method string) context.Context {
+	return grpc.NewContextWithServerTransportStream(context.Background(), &testTransportStream{method: method})
+}
+
+func Test_ExemptMethods(t *testing.T) {
+	interceptor := ExemptMethods(NewAuthenticationInterceptor(&testAuthenticator{}),
+		HealthCheckMethods, ReflectionMethods, "/catalog.v1.CatalogService/ListPublishers")
+
+	testCases := []struct {
+		method string
+		code   codes.Code
+	}{
+		{method: "/grpc.health.v1.Health/Check"},
+		{method: "/grpc.health.v1.Health/Watch"},
+		{method: "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"},
+		{method: "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"},
+		{method: "/catalog.v1.CatalogService/ListPublishers"},
+		{method: "/catalog.v1.CatalogService/ListPublishersAndMore", code: codes.Unauthenticated},
+		{method: "/catalog.v1.CatalogService/CreatePublisher", code: codes.Unauthenticated},
+		{method: "/grpc.health.v2.Health/Check", code: codes.Unauthenticated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method, func(t *testing.T) {
+			_, err := interceptor(methodContext(tc.method))
+			assert.Equal(t, tc.code, status.Code(err))
+		})
+	}
+
+	// Without a method in the context nothing is exempt
+	_, err := interceptor(context.Background())
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func Test_ExemptServerInterceptors(t *testing.T) {
+	deny := func(_ context.Context, _ interface{}, info *grpc.UnaryServerInfo, _ grpc.UnaryHandler) (interface{}, error) {
+		return nil, status.Errorf(codes.PermissionDenied, "access denied to %s", info.FullMethod)
+	}
+	handler := func(_ context.Context, _ interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	interceptor := ExemptUnaryServerInterceptor(deny, HealthCheckMethods)
+
+	resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	assert.NilError(t, err)
+	assert.Equal(t, "ok", resp)
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/catalog.v1.CatalogService/ListPublishers"}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	denyStream := func(_ interface{}, _ grpc.ServerStream, info *grpc.StreamServerInfo, _ grpc.StreamHandler) error {
+		return status.Errorf(codes.PermissionDenied, "access denied to %s", info.FullMethod)
+	}
+	streamHandler := func(_ interface{}, _ grpc.ServerStream) error {
+		return nil
+	}
+	streamInterceptor := ExemptStreamServerInterceptor(denyStream, HealthCheckMethods)
+
+	err = streamInterceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"}, streamHandler)
+	assert.NilError(t, err)
+	err = streamInterceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/catalog.v1.CatalogService/WatchPublishers"}, streamHandler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,294 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+// Package keycloak is a client of the Keycloak admin REST API, covering the clients, roles, groups,
+// users and protocol mappers that services provision in their realm
+package keycloak
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLoginRealm is the default realm of the admin or service account login
+	DefaultLoginRealm = "master"
+	// DefaultAdminCLIClient is the public client Keycloak provides for admin logins with a password
+	DefaultAdminCLIClient = "admin-cli"
+
+	tokenURL      = "/realms/%s/protocol/openid-connect/token"
+	adminRealmURL = "/admin/realms/%s"
+
+	// tokenExpiryMargin is how long before its expiry an access token is replaced
+	tokenExpiryMargin = 10 * time.Second
+)
+
+// Option configures an AdminClient
+type Option func(*AdminClient)
+
+// WithHTTPClient sets the HTTP client used to call Keycloak
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *AdminClient) {
+		c.httpClient = client
+	}
+}
+
+// WithLoginRealm sets the realm the admin or service account logs in to,
+// when it is not the realm being managed. It defaults to DefaultLoginRealm.
+func WithLoginRealm(realm string) Option {
+	return func(c *AdminClient) {
+		c.loginRealm = realm
+	}
+}
+
+// WithPasswordLogin logs in as an admin user with the password grant of the given client,
+// for example DefaultAdminCLIClient
+func WithPasswordLogin(clientID string, username string, password string) Option {
+	return func(c *AdminClient) {
+		c.login = url.Values{
+			"grant_type": {"password"},
+			"client_id":  {clientID},
+			"username":   {username},
+			"password":   {password},
+		}
+	}
+}
+
+// WithClientCredentialsLogin logs in as the service account of a confidential client
+func WithClientCredentialsLogin(clientID string, clientSecret string) Option {
+	return func(c *AdminClient) {
+		c.login = url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {clientSecret},
+		}
+	}
+}
+
+// WithToken uses an access token obtained elsewhere. The token is not renewed.
+func WithToken(token string) Option {
+	return func(c *AdminClient) {
+		c.login = nil
+		c.token = token
+		c.tokenExpiry = time.Time{}
+	}
+}
+
+// AdminClient calls the Keycloak admin REST API of one realm.
+// It is safe for concurrent use; the access token is shared and renewed before it expires.
+// Errors are errors package values: NotFound, Conflict, Unauthorized, Forbidden, Invalid or
+// Unavailable depending on the Keycloak response.
+type AdminClient struct {
+	serverURL  string
+	realm      string
+	loginRealm string
+	httpClient *http.Client
+	login      url.Values
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewAdminClient creates a client of the admin API of the realm on the Keycloak server.
+// One of WithPasswordLogin, WithClientCredentialsLogin or WithToken is required.
+func NewAdminClient(serverURL string, realm string, opts ...Option) (*AdminClient, error) {
+	c := &AdminClient{
+		serverURL:  strings.TrimSuffix(serverURL, "/"),
+		realm:      realm,
+		loginRealm: DefaultLoginRealm,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if c.login == nil && c.token == "" {
+		return nil, errors.NewInvalid("no keycloak credentials configured")
+	}
+	return c, nil
+}
+
+// Realm returns the realm managed by the client
+func (c *AdminClient) Realm() string {
+	return c.realm
+}
+
+// Token returns a valid access token, logging in if there is none or it is about to expire
+func (c *AdminClient) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && (c.login == nil || time.Until(c.tokenExpiry) > tokenExpiryMargin) {
+		return c.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.serverURL+fmt.Sprintf(tokenURL, url.PathEscape(c.loginRealm)),
+		strings.NewReader(c.login.Encode()))
+	if err != nil {
+		return "", errors.NewInvalid("invalid keycloak token request: %v", err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", errors.NewUnavailable("keycloak token request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Keycloak answers a bad password or client secret with 400 or 401 invalid_grant
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return "", errors.NewUnauthorized("keycloak login of %s failed: %s", c.loginName(), errorMessage(resp))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp, "keycloak token request failed")
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", errors.NewInternal("unable to decode keycloak token response: %v", err)
+	}
+	c.token = tokenResp.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+func (c *AdminClient) loginName() string {
+	if username := c.login.Get("username"); username != "" {
+		return username
+	}
+	return c.login.Get("client_id")
+}
+
+// resourcePath is the path of an admin API resource of the realm as unescaped elements,
+// e.g. resourcePath{"clients", id}; adminURL prefixes it with the realm and escapes it
+type resourcePath []string
+
+// adminURL returns the URL of an admin API resource of the realm. The elements are path escaped and
+// must not be empty, "." or "..", so that an ID cannot address another resource, e.g. the realm itself.
+func (c *AdminClient) adminURL(resource resourcePath) (string, error) {
+	escaped := make([]string, 0, len(resource))
+	for _, elem := range resource {
+		if elem == "" || elem == "." || elem == ".." {
+			return "", errors.NewInvalid("invalid keycloak resource path element %q", elem)
+		}
+		escaped = append(escaped, url.PathEscape(elem))
+	}
+	return c.serverURL + fmt.Sprintf(adminRealmURL, url.PathEscape(c.realm)) + "/" + strings.Join(escaped, "/"), nil
+}
+
+// do calls the admin API, sending body as JSON if it is not nil and decoding the response into out
+// if it is not nil. It returns the response headers, for example to read the Location of a created resource.
+func (c *AdminClient) do(ctx context.Context, method string, resource resourcePath, query url.Values, body interface{}, out interface{}) (http.Header, error) {
+	resourceURL, err := c.adminURL(resource)
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.NewInvalid("unable to encode keycloak request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	if len(query) > 0 {
+		resourceURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, resourceURL, reader)
+	if err != nil {
+		return nil, errors.NewInvalid("invalid keycloak request: %v", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("Accept", "application/json")
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.NewUnavailable("keycloak request %s %s failed: %v", method, req.URL.Path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, statusError(resp, fmt.Sprintf("keycloak request %s %s failed", method, req.URL.Path))
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, errors.NewInternal("unable to decode keycloak response: %v", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// create posts a new resource and returns its ID, the last element of the Location header
+func (c *AdminClient) create(ctx context.Context, resource resourcePath, body interface{}) (string, error) {
+	header, err := c.do(ctx, http.MethodPost, resource, nil, body, nil)
+	if err != nil {
+		return "", err
+	}
+	location := header.Get("Location")
+	if location == "" {
+		return "", nil
+	}
+	return path.Base(location), nil
+}
+
+// statusError maps the status of a failed Keycloak response to a typed error
+func statusError(resp *http.Response, msg string) error {
+	msg = fmt.Sprintf("%s %d: %s", msg, resp.StatusCode, errorMessage(resp))
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errors.NewNotFound("%s", msg)
+	case resp.StatusCode == http.StatusConflict:
+		return errors.NewConflict("%s", msg)
+	case resp.StatusCode == http.StatusUnauthorized:
+		return errors.NewUnauthorized("%s", msg)
+	case resp.StatusCode == http.StatusForbidden:
+		return errors.NewForbidden("%s", msg)
+	case resp.StatusCode == http.StatusBadRequest:
+		return errors.NewInvalid("%s", msg)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return errors.NewUnavailable("%s", msg)
+	}
+	return errors.NewUnknown("%s", msg)
+}
+
+// errorMessage reads the error message of a Keycloak error response
+func errorMessage(resp *http.Response) string {
+	var body struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+		ErrorMessage     string `json:"errorMessage"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(data, &body); err != nil {
+		return strings.TrimSpace(string(data))
+	}
+	switch {
+	case body.ErrorMessage != "":
+		return body.ErrorMessage
+	case body.ErrorDescription != "":
+		return body.ErrorDescription
+	}
+	return body.Error
+}
@@ -0,0 +1,412 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"gotest.tools/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testRealm = "orch"
+
+// testKeycloak is an in-memory Keycloak serving the admin login and a subset of the admin API of one realm
+type testKeycloak struct {
+	*httptest.Server
+	mu          sync.Mutex
+	logins      int
+	expiresIn   int
+	nextID      int
+	clients     map[string]*Client
+	secrets     map[string]string
+	mappers     map[string][]ProtocolMapper
+	roles       map[string]*Role
+	users       map[string]*User
+	groups      map[string]*Group
+	userGroups  map[string][]string
+	userRoles   map[string][]Role
+	saUsers     map[string]string
+	failures    int
+	failureCode int
+}
+
+func newTestKeycloak(t *testing.T) *testKeycloak {
+	k := &testKeycloak{
+		expiresIn:  300,
+		clients:    map[string]*Client{},
+		secrets:    map[string]string{},
+		mappers:    map[string][]ProtocolMapper{},
+		roles:      map[string]*Role{},
+		users:      map[string]*User{},
+		groups:     map[string]*Group{},
+		userGroups: map[string][]string{},
+		userRoles:  map[string][]Role{},
+		saUsers:    map[string]string{},
+	}
+	admin := fmt.Sprintf(adminRealmURL, testRealm)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+fmt.Sprintf(tokenURL, DefaultLoginRealm), func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.FormValue("grant_type") == "password" && r.FormValue("password") == "pass":
+		case r.FormValue("grant_type") == "client_credentials" && r.FormValue("client_secret") == "sa-secret":
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid user credentials"}`))
+			return
+		}
+		k.logins++
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":%d}`, k.logins, k.expiresIn)
+	})
+	mux.HandleFunc("GET "+admin+"/clients", func(w http.ResponseWriter, r *http.Request) {
+		clients := []*Client{}
+		for _, c := range k.clients {
+			if clientID := r.URL.Query().Get("clientId"); clientID == "" || strings.HasPrefix(c.ClientID, clientID) {
+				clients = append(clients, c)
+			}
+		}
+		writeJSON(w, clients)
+	})
+	mux.HandleFunc("POST "+admin+"/clients", func(w http.ResponseWriter, r *http.Request) {
+		client := &Client{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(client))
+		for _, c := range k.clients {
+			if c.ClientID == client.ClientID {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = fmt.Fprintf(w, `{"errorMessage":"Client %s already exists"}`, c.ClientID)
+				return
+			}
+		}
+		client.ID = k.newID(w, r)
+		k.clients[client.ID] = client
+		k.secrets[client.ID] = "secret-" + client.ID
+		if client.ServiceAccountsEnabled != nil && *client.ServiceAccountsEnabled {
+			user := &User{ID: k.newID(nil, nil), Username: "service-account-" + client.ClientID, ServiceAccountClientID: client.ID}
+			k.users[user.ID] = user
+			k.saUsers[client.ID] = user.ID
+		}
+	})
+	mux.HandleFunc("GET "+admin+"/clients/{id}", func(w http.ResponseWriter, r *http.Request) {
+		writeFound(w, k.clients[r.PathValue("id")])
+	})
+	mux.HandleFunc("DELETE "+admin+"/clients/{id}", func(w http.ResponseWriter, r *http.Request) {
+		delete(k.clients, r.PathValue("id"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET "+admin+"/clients/{id}/client-secret", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, Credential{Type: "secret", Value: k.secrets[r.PathValue("id")]})
+	})
+	mux.HandleFunc("POST "+admin+"/clients/{id}/client-secret", func(w http.ResponseWriter, r *http.Request) {
+		k.secrets[r.PathValue("id")] += "-regenerated"
+		writeJSON(w, Credential{Type: "secret", Value: k.secrets[r.PathValue("id")]})
+	})
+	mux.HandleFunc("GET "+admin+"/clients/{id}/service-account-user", func(w http.ResponseWriter, r *http.Request) {
+		writeFound(w, k.users[k.saUsers[r.PathValue("id")]])
+	})
+	mux.HandleFunc("GET "+admin+"/clients/{id}/protocol-mappers/models", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, k.mappers[r.PathValue("id")])
+	})
+	mux.HandleFunc("POST "+admin+"/clients/{id}/protocol-mappers/models", func(w http.ResponseWriter, r *http.Request) {
+		mapper := ProtocolMapper{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&mapper))
+		mapper.ID = k.newID(w, r)
+		k.mappers[r.PathValue("id")] = append(k.mappers[r.PathValue("id")], mapper)
+	})
+	mux.HandleFunc("POST "+admin+"/roles", func(w http.ResponseWriter, r *http.Request) {
+		role := &Role{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(role))
+		role.ID = k.newID(nil, nil)
+		k.roles[role.Name] = role
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET "+admin+"/roles/{name}", func(w http.ResponseWriter, r *http.Request) {
+		writeFound(w, k.roles[r.PathValue("name")])
+	})
+	mux.HandleFunc("POST "+admin+"/users", func(w http.ResponseWriter, r *http.Request) {
+		user := &User{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(user))
+		user.ID = k.newID(w, r)
+		k.users[user.ID] = user
+	})
+	mux.HandleFunc("GET "+admin+"/users", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("exact"))
+		users := []*User{}
+		for _, u := range k.users {
+			if u.Username == r.URL.Query().Get("username") {
+				users = append(users, u)
+			}
+		}
+		writeJSON(w, users)
+	})
+	mux.HandleFunc("PUT "+admin+"/users/{id}/reset-password", func(w http.ResponseWriter, r *http.Request) {
+		credential := Credential{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&credential))
+		user := k.users[r.PathValue("id")]
+		user.Credentials = []Credential{credential}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("PUT "+admin+"/users/{id}/groups/{group}", func(w http.ResponseWriter, r *http.Request) {
+		k.userGroups[r.PathValue("id")] = append(k.userGroups[r.PathValue("id")], r.PathValue("group"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET "+admin+"/users/{id}/groups", func(w http.ResponseWriter, r *http.Request) {
+		groups := []*Group{}
+		for _, id := range k.userGroups[r.PathValue("id")] {
+			groups = append(groups, k.groups[id])
+		}
+		writeJSON(w, groups)
+	})
+	mux.HandleFunc("POST "+admin+"/users/{id}/role-mappings/realm", func(w http.ResponseWriter, r *http.Request) {
+		var roles []Role
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&roles))
+		k.userRoles[r.PathValue("id")] = append(k.userRoles[r.PathValue("id")], roles...)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET "+admin+"/users/{id}/role-mappings/realm", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, k.userRoles[r.PathValue("id")])
+	})
+	mux.HandleFunc("POST "+admin+"/groups", func(w http.ResponseWriter, r *http.Request) {
+		group := &Group{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(group))
+		group.ID = k.newID(w, r)
+		group.Path = "/" + group.Name
+		k.groups[group.ID] = group
+	})
+	mux.HandleFunc("GET "+admin+"/group-by-path/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		for _, g := range k.groups {
+			if g.Path == "/"+r.PathValue("path") {
+				writeJSON(w, g)
+				return
+			}
+		}
+		writeFound[Group](w, nil)
+	})
+
+	k.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/admin/") {
+			if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", k.logins) &&
+				r.Header.Get("Authorization") != "Bearer static" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if k.failures > 0 {
+				k.failures--
+				w.WriteHeader(k.failureCode)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	return k
+}
+
+// newID returns a new resource ID and, if w is not nil, answers 201 with its Location
+func (k *testKeycloak) newID(w http.ResponseWriter, r *http.Request) string {
+	k.nextID++
+	id := fmt.Sprintf("id-%d", k.nextID)
+	if w != nil {
+		w.Header().Set("Location", k.URL+r.URL.Path+"/"+id)
+		w.WriteHeader(http.StatusCreated)
+	}
+	return id
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeFound[T any](w http.ResponseWriter, v *T) {
+	if v == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"Could not find resource"}`))
+		return
+	}
+	writeJSON(w, v)
+}
+
+func newTestAdminClient(t *testing.T, k *testKeycloak, opts ...Option) *AdminClient {
+	opts = append([]Option{WithPasswordLogin(DefaultAdminCLIClient, "admin", "pass")}, opts...)
+	c, err := NewAdminClient(k.URL, testRealm, opts...)
+	assert.NilError(t, err)
+	return c
+}
+
+func TestAdminClient_Login(t *testing.T) {
+	k := newTestKeycloak(t)
+	defer k.Close()
+	ctx := context.Background()
+
+	_, err := NewAdminClient(k.URL, testRealm)
+	assert.Assert(t, errors.IsInvalid(err))
+
+	c := newTestAdminClient(t, k)
+	for i := 0; i < 3; i++ {
+		_, err := c.ListClients(ctx)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, 1, k.logins)
+
+	// Tokens about to expire are replaced
+	k.expiresIn = 1
+	c = newTestAdminClient(t, k)
+	for i := 0; i < 2; i++ {
+		_, err := c.ListClients(ctx)
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, 3, k.logins)
+
+	c, err = NewAdminClient(k.URL, testRealm, WithClientCredentialsLogin("provisioner", "sa-secret"))
+	assert.NilError(t, err)
+	_, err = c.ListClients(ctx)
+	assert.NilError(t, err)
+
+	c, err = NewAdminClient(k.URL, testRealm, WithToken("static"))
+	assert.NilError(t, err)
+	_, err = c.ListClients(ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, 4, k.logins)
+
+	c = newTestAdminClient(t, k, WithPasswordLogin(DefaultAdminCLIClient, "admin", "wrong"))
+	_, err = c.ListClients(ctx)
+	assert.Assert(t, errors.IsUnauthorized(err))
+	assert.ErrorContains(t, err, "Invalid user credentials")
+}
+
+func TestAdminClient_Errors(t *testing.T) {
+	k := newTestKeycloak(t)
+	ctx := context.Background()
+	c := newTestAdminClient(t, k)
+
+	_, err := c.GetClient(ctx, "missing")
+	assert.Assert(t, errors.IsNotFound(err))
+
+	// IDs cannot address another resource, e.g. the realm or a collection
+	assert.Assert(t, errors.IsInvalid(c.DeleteGroup(ctx, "..")))
+	assert.Assert(t, errors.IsInvalid(c.DeleteUser(ctx, "")))
+	assert.Assert(t, errors.IsInvalid(c.DeleteClientRole(ctx, "client-1", ".")))
+	_, err = c.GetGroupByPath(ctx, "/parent/../other")
+	assert.Assert(t, errors.IsInvalid(err))
+
+	k.failures, k.failureCode = 1, http.StatusForbidden
+	_, err = c.ListClients(ctx)
+	assert.Assert(t, errors.IsForbidden(err))
+
+	k.failures, k.failureCode = 1, http.StatusBadGateway
+	_, err = c.ListClients(ctx)
+	assert.Assert(t, errors.IsUnavailable(err))
+
+	k.Close()
+	_, err = c.ListClients(ctx)
+	assert.Assert(t, errors.IsUnavailable(err))
+}
+
+func TestAdminClient_Clients(t *testing.T) {
+	k := newTestKeycloak(t)
+	defer k.Close()
+	ctx := context.Background()
+	c := newTestAdminClient(t, k)
+
+	id, err := c.CreateClient(ctx, Client{ClientID: "app-m2m", ServiceAccountsEnabled: Bool(true)})
+	assert.NilError(t, err)
+	assert.Assert(t, id != "")
+	_, err = c.CreateClient(ctx, Client{ClientID: "app"})
+	assert.NilError(t, err)
+
+	_, err = c.CreateClient(ctx, Client{ClientID: "app-m2m"})
+	assert.Assert(t, errors.IsConflict(err))
+	assert.ErrorContains(t, err, "Client app-m2m already exists")
+
+	client, err := c.GetClientByClientID(ctx, "app")
+	assert.NilError(t, err)
+	assert.Equal(t, "app", client.ClientID)
+	_, err = c.GetClientByClientID(ctx, "other")
+	assert.Assert(t, errors.IsNotFound(err))
+
+	secret, err := c.GetClientSecret(ctx, id)
+	assert.NilError(t, err)
+	regenerated, err := c.RegenerateClientSecret(ctx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, secret+"-regenerated", regenerated)
+
+	mapperID, err := c.CreateProtocolMapper(ctx, id, ProtocolMapper{
+		Name:           "groups",
+		Protocol:       "openid-connect",
+		ProtocolMapper: "oidc-group-membership-mapper",
+		Config:         map[string]string{"claim.name": "groups"},
+	})
+	assert.NilError(t, err)
+	mappers, err := c.ListProtocolMappers(ctx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(mappers))
+	assert.Equal(t, mapperID, mappers[0].ID)
+	assert.Equal(t, "groups", mappers[0].Config["claim.name"])
+
+	assert.NilError(t, c.DeleteClient(ctx, id))
+	_, err = c.GetClient(ctx, id)
+	assert.Assert(t, errors.IsNotFound(err))
+}
+
+func TestAdminClient_ServiceAccountRoles(t *testing.T) {
+	k := newTestKeycloak(t)
+	defer k.Close()
+	ctx := context.Background()
+	c := newTestAdminClient(t, k)
+
+	id, err := c.CreateClient(ctx, Client{ClientID: "app-m2m", ServiceAccountsEnabled: Bool(true)})
+	assert.NilError(t, err)
+	assert.NilError(t, c.CreateRealmRole(ctx, Role{Name: "app-write-role"}))
+	role, err := c.GetRealmRole(ctx, "app-write-role")
+	assert.NilError(t, err)
+
+	user, err := c.GetServiceAccountUser(ctx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, "service-account-app-m2m", user.Username)
+
+	assert.NilError(t, c.AddServiceAccountRealmRoles(ctx, id, []Role{*role}))
+	roles, err := c.GetServiceAccountRealmRoles(ctx, id)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(roles))
+	assert.Equal(t, role.ID, roles[0].ID)
+}
+
+func TestAdminClient_UsersAndGroups(t *testing.T) {
+	k := newTestKeycloak(t)
+	defer k.Close()
+	ctx := context.Background()
+	c := newTestAdminClient(t, k)
+
+	groupID, err := c.CreateGroup(ctx, Group{Name: "operators"})
+	assert.NilError(t, err)
+	group, err := c.GetGroupByPath(ctx, "/operators")
+	assert.NilError(t, err)
+	assert.Equal(t, groupID, group.ID)
+	_, err = c.GetGroupByPath(ctx, "/admins")
+	assert.Assert(t, errors.IsNotFound(err))
+
+	userID, err := c.CreateUser(ctx, User{Username: "alice", Enabled: Bool(true)})
+	assert.NilError(t, err)
+	user, err := c.GetUserByUsername(ctx, "alice")
+	assert.NilError(t, err)
+	assert.Equal(t, userID, user.ID)
+	_, err = c.GetUserByUsername(ctx, "bob")
+	assert.Assert(t, errors.IsNotFound(err))
+
+	assert.NilError(t, c.SetUserPassword(ctx, userID, "changeit", true))
+	assert.DeepEqual(t, []Credential{{Type: "password", Value: "changeit", Temporary: Bool(true)}}, k.users[userID].Credentials)
+
+	assert.NilError(t, c.AddUserToGroup(ctx, userID, groupID))
+	groups, err := c.ListUserGroups(ctx, userID)
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, "/operators", groups[0].Path)
+}
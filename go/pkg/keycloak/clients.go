@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package keycloak
+
+import (
+	"context"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"net/http"
+	"net/url"
+)
+
+// Clients are addressed by their ID, the UUID Keycloak assigns, except in GetClientByClientID
+
+// ListClients returns the clients of the realm
+func (c *AdminClient) ListClients(ctx context.Context) ([]Client, error) {
+	var clients []Client
+	_, err := c.do(ctx, http.MethodGet, resourcePath{"clients"}, nil, nil, &clients)
+	return clients, err
+}
+
+// GetClient returns the client with the given ID
+func (c *AdminClient) GetClient(ctx context.Context, id string) (*Client, error) {
+	client := &Client{}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath{"clients", id}, nil, nil, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// GetClientByClientID returns the client with the given client ID, the name used in OAuth requests
+func (c *AdminClient) GetClientByClientID(ctx context.Context, clientID string) (*Client, error) {
+	var clients []Client
+	query := url.Values{"clientId": {clientID}}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath{"clients"}, query, nil, &clients); err != nil {
+		return nil, err
+	}
+	// Older Keycloak versions match the clientId query as a prefix
+	for i := range clients {
+		if clients[i].ClientID == clientID {
+			return &clients[i], nil
+		}
+	}
+	return nil, errors.NewNotFound("keycloak client %s not found in realm %s", clientID, c.realm)
+}
+
+// CreateClient creates a client and returns its ID
+func (c *AdminClient) CreateClient(ctx context.Context, client Client) (string, error) {
+	return c.create(ctx, resourcePath{"clients"}, client)
+}
+
+// UpdateClient updates the client with the ID of the given client
+func (c *AdminClient) UpdateClient(ctx context.Context, client Client) error {
+	if client.ID == "" {
+		return errors.NewInvalid("client ID is required")
+	}
+	_, err := c.do(ctx, http.MethodPut, resourcePath{"clients", client.ID}, nil, client, nil)
+	return err
+}
+
+// DeleteClient deletes the client with the given ID
+func (c *AdminClient) DeleteClient(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, resourcePath{"clients", id}, nil, nil, nil)
+	return err
+}
+
+// GetClientSecret returns the secret of a confidential client
+func (c *AdminClient) GetClientSecret(ctx context.Context, id string) (string, error) {
+	credential := &Credential{}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath{"clients", id, "client-secret"}, nil, nil, credential); err != nil {
+		return "", err
+	}
+	return credential.Value, nil
+}
+
+// RegenerateClientSecret replaces the secret of a confidential client and returns the new secret
+func (c *AdminClient) RegenerateClientSecret(ctx context.Context, id string) (string, error) {
+	credential := &Credential{}
+	if _, err := c.do(ctx, http.MethodPost, resourcePath{"clients", id, "client-secret"}, nil, nil, credential); err != nil {
+		return "", err
+	}
+	return credential.Value, nil
+}
+
+// GetServiceAccountUser returns the service account user of a client with service accounts enabled
+func (c *AdminClient) GetServiceAccountUser(ctx context.Context, id string) (*User, error) {
+	user := &User{}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath{"clients", id, "service-account-user"}, nil, nil, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListProtocolMappers returns the protocol mappers of a client
+func (c *AdminClient) ListProtocolMappers(ctx context.Context, id string) ([]ProtocolMapper, error) {
+	var mappers []ProtocolMapper
+	_, err := c.do(ctx, http.MethodGet, resourcePath{"clients", id, "protocol-mappers", "models"}, nil, nil, &mappers)
+	return mappers, err
+}
+
+// CreateProtocolMapper adds a protocol mapper to a client and returns its ID
+func (c *AdminClient) CreateProtocolMapper(ctx context.Context, id string, mapper ProtocolMapper) (string, error) {
+	return c.create(ctx, resourcePath{"clients", id, "protocol-mappers", "models"}, mapper)
+}
+
+// UpdateProtocolMapper updates the protocol mapper of a client with the ID of the given mapper
+func (c *AdminClient) UpdateProtocolMapper(ctx context.Context, id string, mapper ProtocolMapper) error {
+	if mapper.ID == "" {
+		return errors.NewInvalid("protocol mapper ID is required")
+	}
+	_, err := c.do(ctx, http.MethodPut, resourcePath{"clients", id, "protocol-mappers", "models", mapper.ID}, nil, mapper, nil)
+	return err
+}
+
+// DeleteProtocolMapper removes a protocol mapper from a client
+func (c *AdminClient) DeleteProtocolMapper(ctx context.Context, id string, mapperID string) error {
+	_, err := c.do(ctx, http.MethodDelete, resourcePath{"clients", id, "protocol-mappers", "models", mapperID}, nil, nil, nil)
+	return err
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package keycloak
+
+import (
+	"context"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ListGroups returns the top level groups of the realm, with their sub groups
+func (c *AdminClient) ListGroups(ctx context.Context) ([]Group, error) {
+	var groups []Group
+	_, err := c.do(ctx, http.MethodGet, resourcePath{"groups"}, nil, nil, &groups)
+	return groups, err
+}
+
+// GetGroup returns the group with the given ID
+func (c *AdminClient) GetGroup(ctx context.Context, id string) (*Group, error) {
+	group := &Group{}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath{"groups", id}, nil, nil, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// GetGroupByPath returns the group with the given path, for example "/parent/child"
+func (c *AdminClient) GetGroupByPath(ctx context.Context, path string) (*Group, error) {
+	elems := append([]string{"group-by-path"}, strings.Split(strings.Trim(path, "/"), "/")...)
+	group := &Group{}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath(elems), nil, nil, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// SearchGroups returns the groups whose name contains search, with the matching sub groups
+func (c *AdminClient) SearchGroups(ctx context.Context, search string) ([]Group, error) {
+	var groups []Group
+	_, err := c.do(ctx, http.MethodGet, resourcePath{"groups"}, url.Values{"search": {search}}, nil, &groups)
+	return groups, err
+}
+
+// CreateGroup creates a top level group and returns its ID
+func (c *AdminClient) CreateGroup(ctx context.Context, group Group) (string, error) {
+	return c.create(ctx, resourcePath{"groups"}, group)
+}
+
+// CreateSubGroup creates a group below the group with ID parentID and returns its ID
+func (c *AdminClient) CreateSubGroup(ctx context.Context, parentID string, group Group) (string, error) {
+	return c.create(ctx, resourcePath{"groups", parentID, "children"}, group)
+}
+
+// UpdateGroup updates the group with the ID of the given group
+func (c *AdminClient) UpdateGroup(ctx context.Context, group Group) error {
+	if group.ID == "" {
+		return errors.NewInvalid("group ID is required")
+	}
+	_, err := c.do(ctx, http.MethodPut, resourcePath{"groups", group.ID}, nil, group, nil)
+	return err
+}
+
+// DeleteGroup deletes the group with the given ID and its sub groups
+func (c *AdminClient) DeleteGroup(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, resourcePath{"groups", id}, nil, nil, nil)
+	return err
+}
+
+// ListGroupMembers returns the users of a group
+func (c *AdminClient) ListGroupMembers(ctx context.Context, id string) ([]User, error) {
+	var users []User
+	_, err := c.do(ctx, http.MethodGet, resourcePath{"groups", id, "members"}, nil, nil, &users)
+	return users, err
+}
+
+// GetGroupRealmRoles returns the realm roles mapped to a group
+func (c *AdminClient) GetGroupRealmRoles(ctx context.Context, id string) ([]Role, error) {
+	return c.realmRoleMappings(ctx, "groups", id)
+}
+
+// AddGroupRealmRoles maps realm roles to a group
+func (c *AdminClient) AddGroupRealmRoles(ctx context.Context, id string, roles []Role) error {
+	return c.updateRealmRoleMappings(ctx, http.MethodPost, "groups", id, roles)
+}
+
+// RemoveGroupRealmRoles removes realm roles from a group
+func (c *AdminClient) RemoveGroupRealmRoles(ctx context.Context, id string, roles []Role) error {
+	return c.updateRealmRoleMappings(ctx, http.MethodDelete, "groups", id, roles)
+}
+
+// GetGroupClientRoles returns the roles of the client with ID clientID mapped to a group
+func (c *AdminClient) GetGroupClientRoles(ctx context.Context, id string, clientID string) ([]Role, error) {
+	return c.clientRoleMappings(ctx, "groups", id, clientID)
+}
+
+// AddGroupClientRoles maps roles of the client with ID clientID to a group
+func (c *AdminClient) AddGroupClientRoles(ctx context.Context, id string, clientID string, roles []Role) error {
+	return c.updateClientRoleMappings(ctx, http.MethodPost, "groups", id, clientID, roles)
+}
+
+// RemoveGroupClientRoles removes roles of the client with ID clientID from a group
+func (c *AdminClient) RemoveGroupClientRoles(ctx context.Context, id string, clientID string, roles []Role) error {
+	return c.updateClientRoleMappings(ctx, http.MethodDelete, "groups", id, clientID, roles)
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package keycloak
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListRealmRoles returns the roles of the realm
+func (c *AdminClient) ListRealmRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+	_, err := c.do(ctx, http.MethodGet, resourcePath{"roles"}, nil, nil, &roles)
+	return roles, err
+}
+
+// GetRealmRole returns the realm role with the given name
+func (c *AdminClient) GetRealmRole(ctx context.Context, name string) (*Role, error) {
+	role := &Role{}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath{"roles", name}, nil, nil, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// CreateRealmRole creates a realm role
+func (c *AdminClient) CreateRealmRole(ctx context.Context, role Role) error {
+	_, err := c.do(ctx, http.MethodPost, resourcePath{"roles"}, nil, role, nil)
+	return err
+}
+
+// DeleteRealmRole deletes the realm role with the given name
+func (c *AdminClient) DeleteRealmRole(ctx context.Context, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, resourcePath{"roles", name}, nil, nil, nil)
+	return err
+}
+
+// ListClientRoles returns the roles of the client with the given ID
+func (c *AdminClient) ListClientRoles(ctx context.Context, id string) ([]Role, error) {
+	var roles []Role
+	_, err := c.do(ctx, http.MethodGet, resourcePath{"clients", id, "roles"}, nil, nil, &roles)
+	return roles, err
+}
+
+// GetClientRole returns the role with the given name of the client with the given ID
+func (c *AdminClient) GetClientRole(ctx context.Context, id string, name string) (*Role, error) {
+	role := &Role{}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath{"clients", id, "roles", name}, nil, nil, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// CreateClientRole creates a role of the client with the given ID
+func (c *AdminClient) CreateClientRole(ctx context.Context, id string, role Role) error {
+	_, err := c.do(ctx, http.MethodPost, resourcePath{"clients", id, "roles"}, nil, role, nil)
+	return err
+}
+
+// DeleteClientRole deletes the role with the given name of the client with the given ID
+func (c *AdminClient) DeleteClientRole(ctx context.Context, id string, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, resourcePath{"clients", id, "roles", name}, nil, nil, nil)
+	return err
+}
+
+// Role mappings are read and changed with the same endpoints below users/{id} and groups/{id}
+
+func (c *AdminClient) realmRoleMappings(ctx context.Context, kind string, id string) ([]Role, error) {
+	var roles []Role
+	_, err := c.do(ctx, http.MethodGet, resourcePath{kind, id, "role-mappings", "realm"}, nil, nil, &roles)
+	return roles, err
+}
+
+func (c *AdminClient) updateRealmRoleMappings(ctx context.Context, method string, kind string, id string, roles []Role) error {
+	_, err := c.do(ctx, method, resourcePath{kind, id, "role-mappings", "realm"}, nil, roles, nil)
+	return err
+}
+
+func (c *AdminClient) clientRoleMappings(ctx context.Context, kind string, id string, clientID string) ([]Role, error) {
+	var roles []Role
+	_, err := c.do(ctx, http.MethodGet, resourcePath{kind, id, "role-mappings", "clients", clientID}, nil, nil, &roles)
+	return roles, err
+}
+
+func (c *AdminClient) updateClientRoleMappings(ctx context.Context, method string, kind string, id string, clientID string, roles []Role) error {
+	_, err := c.do(ctx, method, resourcePath{kind, id, "role-mappings", "clients", clientID}, nil, roles, nil)
+	return err
+}
+
+// GetServiceAccountRealmRoles returns the realm roles mapped to the service account of the client with the given ID
+func (c *AdminClient) GetServiceAccountRealmRoles(ctx context.Context, id string) ([]Role, error) {
+	user, err := c.GetServiceAccountUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetUserRealmRoles(ctx, user.ID)
+}
+
+// AddServiceAccountRealmRoles maps realm roles to the service account of the client with the given ID
+func (c *AdminClient) AddServiceAccountRealmRoles(ctx context.Context, id string, roles []Role) error {
+	user, err := c.GetServiceAccountUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.AddUserRealmRoles(ctx, user.ID, roles)
+}
+
+// AddServiceAccountClientRoles maps roles of the client roleClientID to the service account
+// of the client with the given ID
+func (c *AdminClient) AddServiceAccountClientRoles(ctx context.Context, id string, roleClientID string, roles []Role) error {
+	user, err := c.GetServiceAccountUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.AddUserClientRoles(ctx, user.ID, roleClientID, roles)
+}
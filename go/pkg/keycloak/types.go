@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package keycloak
+
+// Bool returns a pointer to b, for the optional flags of the representations
+func Bool(b bool) *bool {
+	return &b
+}
+
+// Client is a Keycloak client representation.
+// Unset optional fields are left unchanged by UpdateClient.
+type Client struct {
+	ID                        string            `json:"id,omitempty"`
+	ClientID                  string            `json:"clientId,omitempty"`
+	Name                      string            `json:"name,omitempty"`
+	Description               string            `json:"description,omitempty"`
+	Enabled                   *bool             `json:"enabled,omitempty"`
+	Protocol                  string            `json:"protocol,omitempty"`
+	PublicClient              *bool             `json:"publicClient,omitempty"`
+	BearerOnly                *bool             `json:"bearerOnly,omitempty"`
+	ClientAuthenticatorType   string            `json:"clientAuthenticatorType,omitempty"`
+	Secret                    string            `json:"secret,omitempty"`
+	StandardFlowEnabled       *bool             `json:"standardFlowEnabled,omitempty"`
+	ImplicitFlowEnabled       *bool             `json:"implicitFlowEnabled,omitempty"`
+	DirectAccessGrantsEnabled *bool             `json:"directAccessGrantsEnabled,omitempty"`
+	ServiceAccountsEnabled    *bool             `json:"serviceAccountsEnabled,omitempty"`
+	FullScopeAllowed          *bool             `json:"fullScopeAllowed,omitempty"`
+	RootURL                   string            `json:"rootUrl,omitempty"`
+	BaseURL                   string            `json:"baseUrl,omitempty"`
+	RedirectURIs              []string          `json:"redirectUris,omitempty"`
+	WebOrigins                []string          `json:"webOrigins,omitempty"`
+	Attributes                map[string]string `json:"attributes,omitempty"`
+	DefaultClientScopes       []string          `json:"defaultClientScopes,omitempty"`
+	OptionalClientScopes      []string          `json:"optionalClientScopes,omitempty"`
+	ProtocolMappers           []ProtocolMapper  `json:"protocolMappers,omitempty"`
+}
+
+// Credential is a Keycloak credential representation, used for client secrets and user passwords
+type Credential struct {
+	Type      string `json:"type,omitempty"`
+	Value     string `json:"value,omitempty"`
+	Temporary *bool  `json:"temporary,omitempty"`
+}
+
+// Role is a Keycloak realm or client role representation
+type Role struct {
+	ID          string              `json:"id,omitempty"`
+	Name        string              `json:"name,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Composite   bool                `json:"composite,omitempty"`
+	ClientRole  bool                `json:"clientRole,omitempty"`
+	ContainerID string              `json:"containerId,omitempty"`
+	Attributes  map[string][]string `json:"attributes,omitempty"`
+}
+
+// Group is a Keycloak group representation
+type Group struct {
+	ID          string              `json:"id,omitempty"`
+	Name        string              `json:"name,omitempty"`
+	Path        string              `json:"path,omitempty"`
+	Attributes  map[string][]string `json:"attributes,omitempty"`
+	RealmRoles  []string            `json:"realmRoles,omitempty"`
+	ClientRoles map[string][]string `json:"clientRoles,omitempty"`
+	SubGroups   []Group             `json:"subGroups,omitempty"`
+}
+
+// User is a Keycloak user representation.
+// Unset optional fields are left unchanged by UpdateUser.
+type User struct {
+	ID                     string              `json:"id,omitempty"`
+	Username               string              `json:"username,omitempty"`
+	Email                  string              `json:"email,omitempty"`
+	FirstName              string              `json:"firstName,omitempty"`
+	LastName               string              `json:"lastName,omitempty"`
+	Enabled                *bool               `json:"enabled,omitempty"`
+	EmailVerified          *bool               `json:"emailVerified,omitempty"`
+	Attributes             map[string][]string `json:"attributes,omitempty"`
+	ServiceAccountClientID string              `json:"serviceAccountClientId,omitempty"`
+	// Groups are the paths of the groups a new user joins; it is only read by CreateUser
+	Groups []string `json:"groups,omitempty"`
+	// Credentials are the initial credentials of a new user; it is only read by CreateUser
+	Credentials []Credential `json:"credentials,omitempty"`
+}
+
+// ProtocolMapper is a Keycloak protocol mapper representation, mapping user or client data into tokens
+type ProtocolMapper struct {
+	ID             string            `json:"id,omitempty"`
+	Name           string            `json:"name,omitempty"`
+	Protocol       string            `json:"protocol,omitempty"`
+	ProtocolMapper string            `json:"protocolMapper,omitempty"`
+	Config         map[string]string `json:"config,omitempty"`
+}
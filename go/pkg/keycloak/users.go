@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package keycloak
+
+import (
+	"context"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"net/http"
+	"net/url"
+)
+
+// GetUser returns the user with the given ID
+func (c *AdminClient) GetUser(ctx context.Context, id string) (*User, error) {
+	user := &User{}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath{"users", id}, nil, nil, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserByUsername returns the user with the given username
+func (c *AdminClient) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	var users []User
+	query := url.Values{"username": {username}, "exact": {"true"}}
+	if _, err := c.do(ctx, http.MethodGet, resourcePath{"users"}, query, nil, &users); err != nil {
+		return nil, err
+	}
+	for i := range users {
+		if users[i].Username == username {
+			return &users[i], nil
+		}
+	}
+	return nil, errors.NewNotFound("keycloak user %s not found in realm %s", username, c.realm)
+}
+
+// SearchUsers returns the users whose username, e-mail, first or last name contain search
+func (c *AdminClient) SearchUsers(ctx context.Context, search string) ([]User, error) {
+	var users []User
+	_, err := c.do(ctx, http.MethodGet, resourcePath{"users"}, url.Values{"search": {search}}, nil, &users)
+	return users, err
+}
+
+// CreateUser creates a user and returns its ID
+func (c *AdminClient) CreateUser(ctx context.Context, user User) (string, error) {
+	return c.create(ctx, resourcePath{"users"}, user)
+}
+
+// UpdateUser updates the user with the ID of the given user
+func (c *AdminClient) UpdateUser(ctx context.Context, user User) error {
+	if user.ID == "" {
+		return errors.NewInvalid("user ID is required")
+	}
+	_, err := c.do(ctx, http.MethodPut, resourcePath{"users", user.ID}, nil, user, nil)
+	return err
+}
+
+// DeleteUser deletes the user with the given ID
+func (c *AdminClient) DeleteUser(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, resourcePath{"users", id}, nil, nil, nil)
+	return err
+}
+
+// SetUserPassword sets the password of a user; a temporary password must be changed at the next login
+func (c *AdminClient) SetUserPassword(ctx context.Context, id string, password string, temporary bool) error {
+	credential := Credential{Type: "password", Value: password, Temporary: Bool(temporary)}
+	_, err := c.do(ctx, http.MethodPut, resourcePath{"users", id, "reset-password"}, nil, credential, nil)
+	return err
+}
+
+// ListUserGroups returns the groups of a user
+func (c *AdminClient) ListUserGroups(ctx context.Context, id string) ([]Group, error) {
+	var groups []Group
+	_, err := c.do(ctx, http.MethodGet, resourcePath{"users", id, "groups"}, nil, nil, &groups)
+	return groups, err
+}
+
+// AddUserToGroup adds a user to a group
+func (c *AdminClient) AddUserToGroup(ctx context.Context, id string, groupID string) error {
+	_, err := c.do(ctx, http.MethodPut, resourcePath{"users", id, "groups", groupID}, nil, nil, nil)
+	return err
+}
+
+// RemoveUserFromGroup removes a user from a group
+func (c *AdminClient) RemoveUserFromGroup(ctx context.Context, id string, groupID string) error {
+	_, err := c.do(ctx, http.MethodDelete, resourcePath{"users", id, "groups", groupID}, nil, nil, nil)
+	return err
+}
+
+// GetUserRealmRoles returns the realm roles mapped to a user
+func (c *AdminClient) GetUserRealmRoles(ctx context.Context, id string) ([]Role, error) {
+	return c.realmRoleMappings(ctx, "users", id)
+}
+
+// AddUserRealmRoles maps realm roles to a user
+func (c *AdminClient) AddUserRealmRoles(ctx context.Context, id string, roles []Role) error {
+	return c.updateRealmRoleMappings(ctx, http.MethodPost, "users", id, roles)
+}
+
+// RemoveUserRealmRoles removes realm roles from a user
+func (c *AdminClient) RemoveUserRealmRoles(ctx context.Context, id string, roles []Role) error {
+	return c.updateRealmRoleMappings(ctx, http.MethodDelete, "users", id, roles)
+}
+
+// GetUserClientRoles returns the roles of the client with ID clientID mapped to a user
+func (c *AdminClient) GetUserClientRoles(ctx context.Context, id string, clientID string) ([]Role, error) {
+	return c.clientRoleMappings(ctx, "users", id, clientID)
+}
+
+// AddUserClientRoles maps roles of the client with ID clientID to a user
+func (c *AdminClient) AddUserClientRoles(ctx context.Context, id string, clientID string, roles []Role) error {
+	return c.updateClientRoleMappings(ctx, http.MethodPost, "users", id, clientID, roles)
+}
+
+// RemoveUserClientRoles removes roles of the client with ID clientID from a user
+func (c *AdminClient) RemoveUserClientRoles(ctx context.Context, id string, clientID string, roles []Role) error {
+	return c.updateClientRoleMappings(ctx, http.MethodDelete, "users", id, clientID, roles)
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ContextPrincipalKey is the gin context key of the authenticated auth.Principal
+	ContextPrincipalKey = "principal"
+	// ContextClaimsKey is the gin context key of the jwt.MapClaims of the authenticated token
+	ContextClaimsKey = "claims"
+
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "bearer "
+)
+
+// AuthenticationOption configures the Authentication middleware
+type AuthenticationOption func(*authenticationConfig)
+
+type authenticationConfig struct {
+	exemptRoutes routeMatcher
+}
+
+// WithExemptRoutes lets requests to the given routes through without authentication, e.g. health checks.
+// A route is a path template as registered with gin, e.g. "/v1/projects/:project", optionally preceded by
+// a method and a space, e.g. "GET /healthz". A path ending with "*" matches all paths with that prefix.
+func WithExemptRoutes(routes ...string) AuthenticationOption {
+	return func(cfg *authenticationConfig) {
+		cfg.exemptRoutes = cfg.exemptRoutes.add(routes...)
+	}
+}
+
+// Authentication a middleware to authenticate the bearer token of the Authorization header with the given
+// authenticator, e.g. an auth.JwtAuthenticator, and to store the principal and the claims in the gin context
+// and the request context, see GetPrincipal, GetClaims and auth.PrincipalFromContext. Failures are answered
+// with 401, or 503 if the identity provider is unavailable, and the standard error body with a fixed message;
+// the error of the authenticator is only logged.
+func Authentication(authenticator auth.Authenticator, opts ...AuthenticationOption) gin.HandlerFunc {
+	cfg := &authenticationConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(c *gin.Context) {
+		if cfg.exemptRoutes.match(c) {
+			c.Next()
+			return
+		}
+
+		header := c.GetHeader(authorizationHeader)
+		if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			abortWithError(c, http.StatusUnauthorized, "Request unauthenticated with bearer")
+			return
+		}
+
+		claims, err := authenticator.Authenticate(strings.TrimSpace(header[len(bearerPrefix):]))
+		if err != nil {
+			// The error may name the identity provider and carry upstream error text,
+			// so it is only logged and the client gets a fixed message
+			if status.Code(err) == codes.Unavailable {
+				log.Warnf("Authentication of %s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+				abortWithError(c, http.StatusServiceUnavailable, "authentication service unavailable")
+				return
+			}
+			log.Debugf("Authentication of %s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+			abortWithError(c, http.StatusUnauthorized, "invalid or missing bearer token")
+			return
+		}
+
+		SetPrincipal(c, auth.NewPrincipal(claims))
+		c.Next()
+	}
+}
+
+// SetPrincipal stores the principal and its claims in the gin context and the request context
+func SetPrincipal(c *gin.Context, principal *auth.Principal) {
+	c.Set(ContextPrincipalKey, principal)
+	c.Set(ContextClaimsKey, principal.Claims)
+	c.Request = c.Request.WithContext(auth.NewContextWithPrincipal(c.Request.Context(), principal))
+}
+
+// GetPrincipal returns the authenticated principal stored in the gin context
+func GetPrincipal(c *gin.Context) (*auth.Principal, bool) {
+	value, ok := c.Get(ContextPrincipalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*auth.Principal)
+	return principal, ok && principal != nil
+}
+
+// GetClaims returns the claims of the authenticated token stored in the gin context
+func GetClaims(c *gin.Context) (jwt.MapClaims, bool) {
+	value, ok := c.Get(ContextClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(jwt.MapClaims)
+	return claims, ok && claims != nil
+}
+
+func abortWithError(c *gin.Context, httpStatus int, message string) {
+	c.AbortWithStatusJSON(httpStatus, gin.H{
+		"code":    httpStatus,
+		"message": message,
+		"details": []string{},
+	})
+}
@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package gin
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/openidconnect/oidctest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testAuthenticator struct {
+	tokens map[string]jwt.MapClaims
+	err    error
+}
+
+func (a *testAuthenticator) Authenticate(token string) (jwt.MapClaims, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	claims, ok := a.tokens[token]
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "token is not valid")
+	}
+	return claims, nil
+}
+
+// TestAuthentication tests the authentication middleware populates the principal
+func TestAuthentication(t *testing.T) {
+	authenticator := &testAuthenticator{tokens: map[string]jwt.MapClaims{
+		"user-token": {
+			"sub":          "user",
+			"realm_access": map[string]interface{}{"roles": []interface{}{"reader"}},
+		},
+	}}
+
+	testCases := []struct {
+		name           string
+		authorization  string
+		err            error
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:           "Valid token",
+			authorization:  "Bearer user-token",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Valid token lower case scheme",
+			authorization:  "bearer user-token",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing token",
+			expectedStatus: http.StatusUnauthorized,
+			expectedMsg:    "Request unauthenticated with bearer",
+		},
+		{
+			name:           "Basic credentials",
+			authorization:  "Basic dXNlcjpwYXNz",
+			expectedStatus: http.StatusUnauthorized,
+			expectedMsg:    "Request unauthenticated with bearer",
+		},
+		{
+			name:           "Invalid token",
+			authorization:  "Bearer other-token",
+			expectedStatus: http.StatusUnauthorized,
+			expectedMsg:    "invalid or missing bearer token",
+		},
+		{
+			name:           "Identity provider unavailable",
+			authorization:  "Bearer user-token",
+			err:            status.Errorf(codes.Unavailable, "unable to refresh keys from https://idp.internal/certs: dial tcp: connection refused"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedMsg:    "authentication service unavailable",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator.err = tc.err
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(Authentication(authenticator))
+			router.GET("/test", func(c *gin.Context) {
+				principal, ok := GetPrincipal(c)
+				assert.True(t, ok)
+				assert.True(t, principal.HasRole("reader"))
+				fromCtx, ok := auth.PrincipalFromContext(c.Request.Context())
+				assert.True(t, ok)
+				assert.Equal(t, "user", fromCtx.Subject)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				var body struct {
+					Code    int      `json:"code"`
+					Message string   `json:"message"`
+					Details []string `json:"details"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tc.expectedStatus, body.Code)
+				assert.Equal(t, tc.expectedMsg, body.Message)
+			}
+		})
+	}
+}
+
+// TestAuthenticationJwt tests the middleware with a JwtAuthenticator and exempt routes
+func TestAuthenticationJwt(t *testing.T) {
+	p := oidctest.NewProvider()
+	defer p.Close()
+	authenticator := auth.NewJwtAuthenticator(auth.WithIssuer(p.Issuer()))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Authentication(authenticator,
+		WithExemptRoutes("GET /healthz", "/docs/*", "POST /v1/projects/:project/public")))
+	handler := func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if ok {
+			c.String(http.StatusOK, claims["sub"].(string))
+			return
+		}
+		c.String(http.StatusOK, "anonymous")
+	}
+	router.GET("/healthz", handler)
+	router.HEAD("/healthz", handler)
+	router.GET("/docs/*file", handler)
+	router.GET("/v1/projects/:project", handler)
+	router.POST("/v1/projects/:project/public", handler)
+	router.GET("/v1/projects/:project/public", handler)
+
+	validToken := p.IssueToken(jwt.MapClaims{"sub": "user"})
+	expiredToken := p.IssueToken(jwt.MapClaims{"sub": "user"}, oidctest.Expired())
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Valid token",
+			method:         http.MethodGet,
+			path:           "/v1/projects/p1",
+			token:          validToken,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "user",
+		},
+		{
+			name:           "Expired token",
+			method:         http.MethodGet,
+			path:           "/v1/projects/p1",
+			token:          expiredToken,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Missing token",
+			method:         http.MethodGet,
+			path:           "/v1/projects/p1",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Exempt route",
+			method:         http.MethodGet,
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "anonymous",
+		},
+		{
+			name:           "Exempt route other method",
+			method:         http.MethodHead,
+			path:           "/healthz",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Exempt prefix",
+			method:         http.MethodGet,
+			path:           "/docs/openapi.yaml",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "anonymous",
+		},
+		{
+			name:           "Exempt route template",
+			method:         http.MethodPost,
+			path:           "/v1/projects/p1/public",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "anonymous",
+		},
+		{
+			name:           "Route template other method",
+			method:         http.MethodGet,
+			path:           "/v1/projects/p1/public",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package gin
+
+import (
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+	"github.com/open-edge-platform/orch-library/go/dazl"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"github.com/open-edge-platform/orch-library/go/pkg/openpolicyagent"
+	"net/http"
+	"strings"
+)
+
+var log = dazl.GetLogger()
+
+const (
+	// OpaInputOperationIDKey is the key of the OpenAPI operationId in the OPA input
+	OpaInputOperationIDKey = "operationId"
+	// OpaInputMethodKey is the key of the HTTP method in the OPA input
+	OpaInputMethodKey = "method"
+	// OpaInputPathKey is the key of the OpenAPI path template, e.g. /v1/projects/{project}, in the OPA input
+	OpaInputPathKey = "path"
+	// OpaInputParamsKey is the key of the path parameters by name in the OPA input
+	OpaInputParamsKey = "params"
+	// OpaInputQueryKey is the key of the query parameters in the OPA input
+	OpaInputQueryKey = "query"
+	// OpaInputPrincipalKey is the key of the authenticated principal in the OPA input;
+	// it is absent for unauthenticated requests
+	OpaInputPrincipalKey = "principal"
+	// OpaInputClaimsKey is the key of the verified claims of the caller in the OPA input;
+	// it is absent for unauthenticated requests
+	OpaInputClaimsKey = "claims"
+)
+
+// RuleResolver maps an OpenAPI operationId to the Rego rule to query
+type RuleResolver func(operationID string) string
+
+// AuthorizationOption configures the OpenAPIAuthorization middleware
+type AuthorizationOption func(*openAPIAuthorizer)
+
+// WithRuleResolver sets a function used to choose the Rego rule per operation.
+// When the resolver returns an empty string the default rule is used.
+func WithRuleResolver(resolver RuleResolver) AuthorizationOption {
+	return func(a *openAPIAuthorizer) {
+		a.ruleResolver = resolver
+	}
+}
+
+// WithBasePath sets the path prefix the API is served under, which is removed from the
+// request path before it is matched against the paths of the spec
+func WithBasePath(basePath string) AuthorizationOption {
+	return func(a *openAPIAuthorizer) {
+		a.basePath = strings.TrimSuffix(basePath, "/")
+	}
+}
+
+// WithAuthorizationExemptRoutes lets requests to the given routes through without authorization.
+// Routes have the same form as in WithExemptRoutes. Requests to routes that are not exempt and
+// not in the spec are denied.
+func WithAuthorizationExemptRoutes(routes ...string) AuthorizationOption {
+	return func(a *openAPIAuthorizer) {
+		a.exemptRoutes = a.exemptRoutes.add(routes...)
+	}
+}
+
+type openAPIAuthorizer struct {
+	router       routers.Router
+	evaluator    openpolicyagent.Evaluator
+	pkg          string
+	rule         string
+	ruleResolver RuleResolver
+	basePath     string
+	exemptRoutes routeMatcher
+}
+
+// OpenAPIAuthorization a middleware to authorize requests by querying an Open Policy Agent rule.
+// Each request is resolved to its operation in the OpenAPI spec, and the operationId, method,
+// path template, path and query parameters and the principal stored by the Authentication
+// middleware are sent to OPA, so that one policy per operationId can be shared by REST APIs.
+// The rule is evaluated with the evaluator, e.g. an openpolicyagent.PolicyClient. The servers of
+// the spec are ignored, see WithBasePath. Denied requests are answered with 403.
+func OpenAPIAuthorization(spec *openapi3.T, evaluator openpolicyagent.Evaluator, pkg string, rule string,
+	opts ...AuthorizationOption) (gin.HandlerFunc, error) {
+	// Match on the path only; the host and scheme of a request seen by the server
+	// seldom match the servers listed in the spec
+	doc := *spec
+	doc.Servers = nil
+	router, err := legacy.NewRouter(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create OpenAPI router: %w", err)
+	}
+
+	a := &openAPIAuthorizer{
+		router:    router,
+		evaluator: evaluator,
+		pkg:       pkg,
+		rule:      rule,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a.authorize, nil
+}
+
+func (a *openAPIAuthorizer) authorize(c *gin.Context) {
+	if a.exemptRoutes.match(c) {
+		c.Next()
+		return
+	}
+
+	route, pathParams, err := a.findRoute(c.Request)
+	if err != nil {
+		log.Debugf("No OpenAPI operation for %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		abortWithError(c, http.StatusForbidden, "access denied")
+		return
+	}
+	operationID := route.Operation.OperationID
+
+	rule := a.rule
+	if a.ruleResolver != nil {
+		if r := a.ruleResolver(operationID); r != "" {
+			rule = r
+		}
+	}
+
+	decision, err := a.evaluator.Evaluate(c.Request.Context(), a.pkg, rule, a.buildInput(c, route, pathParams))
+	if err != nil {
+		log.Warnf("OPA query %s/%s failed for %s: %v", a.pkg, rule, operationID, err)
+		if errors.IsUnavailable(err) || errors.IsTimeout(err) {
+			abortWithError(c, http.StatusServiceUnavailable, "unable to reach authorization service")
+			return
+		}
+		abortWithError(c, http.StatusInternalServerError, "authorization failed")
+		return
+	}
+
+	// An undefined rule or a non-boolean result is treated as a deny
+	allowed := decision.Allowed()
+	if !allowed {
+		log.Debugf("Access denied by OPA %s/%s for %s", a.pkg, rule, operationID)
+		abortWithError(c, http.StatusForbidden, fmt.Sprintf("access denied to %s", operationID))
+		return
+	}
+	c.Next()
+}
+
+func (a *openAPIAuthorizer) findRoute(req *http.Request) (*routers.Route, map[string]string, error) {
+	if a.basePath != "" {
+		path, ok := strings.CutPrefix(req.URL.Path, a.basePath)
+		// The base path must be a whole path segment, /api does not match /apiv2
+		if !ok || path != "" && !strings.HasPrefix(path, "/") {
+			return nil, nil, routers.ErrPathNotFound
+		}
+		u := *req.URL
+		u.Path = path
+		u.RawPath = ""
+		r := *req
+		r.URL = &u
+		req = &r
+	}
+	route, pathParams, err := a.router.FindRoute(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if route == nil || route.Operation == nil {
+		return nil, nil, routers.ErrPathNotFound
+	}
+	return route, pathParams, nil
+}
+
+func (a *openAPIAuthorizer) buildInput(c *gin.Context, route *routers.Route, pathParams map[string]string) openpolicyagent.OpaInput {
+	input := map[string]interface{}{
+		OpaInputOperationIDKey: route.Operation.OperationID,
+		OpaInputMethodKey:      route.Method,
+		OpaInputPathKey:        route.Path,
+		OpaInputParamsKey:      pathParams,
+		OpaInputQueryKey:       map[string][]string(c.Request.URL.Query()),
+	}
+	if principal, ok := GetPrincipal(c); ok {
+		clientRoles := make(map[string]interface{}, len(principal.ClientRoles))
+		for client, roles := range principal.ClientRoles {
+			clientRoles[client] = roles
+		}
+		input[OpaInputPrincipalKey] = map[string]interface{}{
+			"subject":         principal.Subject,
+			"username":        principal.Username,
+			"email":           principal.Email,
+			"authorizedParty": principal.AuthorizedParty,
+			"realmRoles":      principal.RealmRoles,
+			"clientRoles":     clientRoles,
+			"groups":          principal.Groups,
+		}
+		input[OpaInputClaimsKey] = map[string]interface{}(principal.Claims)
+	}
+	return openpolicyagent.OpaInput{Input: input}
+}
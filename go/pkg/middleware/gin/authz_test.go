@@ -0,0 +1,295 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package gin
+
+import (
+	"context"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"github.com/open-edge-platform/orch-library/go/pkg/openpolicyagent"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testSpec = `openapi: 3.0.0
+info:
+  title: Test API
+  version: 1.0.0
+servers:
+  - url: https://api.example.com/api
+paths:
+  /v1/projects/{project}/apps:
+    parameters:
+      - name: project
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      operationId: ListApps
+      responses:
+        "200":
+          description: OK
+    post:
+      operationId: CreateApp
+      responses:
+        "201":
+          description: Created
+  /v1/projects/{project}/apps/{app}:
+    parameters:
+      - name: project
+        in: path
+        required: true
+        schema:
+          type: string
+      - name: app
+        in: path
+        required: true
+        schema:
+          type: string
+    delete:
+      operationId: DeleteApp
+      responses:
+        "204":
+          description: Deleted
+`
+
+func opaResult(t *testing.T, statusCode int, result interface{}) *openpolicyagent.PostV1DataPackageRuleResponse {
+	resp := &openpolicyagent.PostV1DataPackageRuleResponse{
+		HTTPResponse: &http.Response{StatusCode: statusCode, Status: fmt.Sprintf("%d", statusCode)},
+	}
+	if statusCode != http.StatusOK {
+		return resp
+	}
+	resp.JSON200 = &openpolicyagent.OpaResponse{}
+	if allowed, ok := result.(bool); ok {
+		assert.NoError(t, resp.JSON200.Result.FromOpaResponseResult1(allowed))
+	}
+	return resp
+}
+
+type evaluatorFunc func(ctx context.Context, pkg string, rule string, input interface{}) (*openpolicyagent.Decision, error)
+
+func (f evaluatorFunc) Evaluate(ctx context.Context, pkg string, rule string, input interface{}) (*openpolicyagent.Decision, error) {
+	return f(ctx, pkg, rule, input)
+}
+
+func newAuthorizationRouter(t *testing.T, client openpolicyagent.ClientWithResponsesInterface, opts ...AuthorizationOption) *gin.Engine {
+	return newEvaluatorAuthorizationRouter(t, openpolicyagent.NewServerEvaluator(client), opts...)
+}
+
+func newEvaluatorAuthorizationRouter(t *testing.T, evaluator openpolicyagent.Evaluator, opts ...AuthorizationOption) *gin.Engine {
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(testSpec))
+	assert.NoError(t, err)
+	authorization, err := OpenAPIAuthorization(spec, evaluator, "apps", "allow", opts...)
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		SetPrincipal(c, auth.NewPrincipal(jwt.MapClaims{
+			"sub":          "user",
+			"realm_access": map[string]interface{}{"roles": []interface{}{"app-reader"}},
+		}))
+	}, authorization)
+	handler := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+	router.GET("/api/v1/projects/:project/apps", handler)
+	router.POST("/api/v1/projects/:project/apps", handler)
+	router.DELETE("/api/v1/projects/:project/apps/:app", handler)
+	router.GET("/api/v1/projects/:project/settings", handler)
+	router.GET("/healthz", handler)
+	return router
+}
+
+// TestOpenAPIAuthorizationInput tests the OPA input is built from the OpenAPI operation and the principal
+func TestOpenAPIAuthorizationInput(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	opaClient := openpolicyagent.NewMockClientWithResponsesInterface(ctrl)
+
+	opaClient.EXPECT().PostV1DataPackageRuleWithResponse(gomock.Any(), "apps", "allow", nil, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ string, _ *openpolicyagent.PostV1DataPackageRuleParams,
+			body openpolicyagent.OpaInput, _ ...openpolicyagent.RequestEditorFn) (*openpolicyagent.PostV1DataPackageRuleResponse, error) {
+			assert.Equal(t, "DeleteApp", body.Input[OpaInputOperationIDKey])
+			assert.Equal(t, http.MethodDelete, body.Input[OpaInputMethodKey])
+			assert.Equal(t, "/v1/projects/{project}/apps/{app}", body.Input[OpaInputPathKey])
+			assert.Equal(t, map[string]string{"project": "p1", "app": "a1"}, body.Input[OpaInputParamsKey])
+			assert.Equal(t, map[string][]string{"force": {"true"}}, body.Input[OpaInputQueryKey])
+			principal, ok := body.Input[OpaInputPrincipalKey].(map[string]interface{})
+			assert.True(t, ok)
+			assert.Equal(t, "user", principal["subject"])
+			assert.Equal(t, []string{"app-reader"}, principal["realmRoles"])
+			claims, ok := body.Input[OpaInputClaimsKey].(map[string]interface{})
+			assert.True(t, ok)
+			assert.Equal(t, "user", claims["sub"])
+			return opaResult(t, http.StatusOK, true), nil
+		})
+
+	router := newAuthorizationRouter(t, opaClient, WithBasePath("/api"))
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/projects/p1/apps/a1?force=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestOpenAPIAuthorization tests the responses of the authorization middleware
+func TestOpenAPIAuthorization(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		response       *openpolicyagent.PostV1DataPackageRuleResponse
+		err            error
+		expectedRule   string
+		expectedStatus int
+	}{
+		{
+			name:           "Allow",
+			method:         http.MethodGet,
+			path:           "/api/v1/projects/p1/apps",
+			response:       opaResult(t, http.StatusOK, true),
+			expectedRule:   "allow",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Deny",
+			method:         http.MethodGet,
+			path:           "/api/v1/projects/p1/apps",
+			response:       opaResult(t, http.StatusOK, false),
+			expectedRule:   "allow",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Undefined result",
+			method:         http.MethodGet,
+			path:           "/api/v1/projects/p1/apps",
+			response:       opaResult(t, http.StatusOK, nil),
+			expectedRule:   "allow",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Rule per operation",
+			method:         http.MethodPost,
+			path:           "/api/v1/projects/p1/apps",
+			response:       opaResult(t, http.StatusOK, true),
+			expectedRule:   "CreateApp",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "OPA error",
+			method:         http.MethodGet,
+			path:           "/api/v1/projects/p1/apps",
+			response:       opaResult(t, http.StatusInternalServerError, nil),
+			expectedRule:   "allow",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "OPA unreachable",
+			method:         http.MethodGet,
+			path:           "/api/v1/projects/p1/apps",
+			err:            fmt.Errorf("connection refused"),
+			expectedRule:   "allow",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "Operation not in spec",
+			method:         http.MethodGet,
+			path:           "/api/v1/projects/p1/settings",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Path outside the base path",
+			method:         http.MethodGet,
+			path:           "/apiv1/projects/p1/apps",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Exempt route",
+			method:         http.MethodGet,
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			opaClient := openpolicyagent.NewMockClientWithResponsesInterface(ctrl)
+			if tc.expectedRule != "" {
+				opaClient.EXPECT().PostV1DataPackageRuleWithResponse(gomock.Any(), "apps", tc.expectedRule, nil, gomock.Any()).
+					Return(tc.response, tc.err)
+			}
+
+			router := newAuthorizationRouter(t, opaClient,
+				WithBasePath("/api"),
+				WithAuthorizationExemptRoutes("GET /healthz"),
+				WithRuleResolver(func(operationID string) string {
+					if operationID == "CreateApp" {
+						return operationID
+					}
+					return ""
+				}))
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+// TestOpenAPIAuthorizationPolicyClient tests the middleware with a PolicyClient and the mapping of typed errors
+func TestOpenAPIAuthorizationPolicyClient(t *testing.T) {
+	testCases := []struct {
+		name           string
+		decision       *openpolicyagent.Decision
+		err            error
+		expectedStatus int
+	}{
+		{
+			name:           "Allow",
+			decision:       &openpolicyagent.Decision{Result: []byte("true")},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Non boolean result",
+			decision:       &openpolicyagent.Decision{Result: []byte(`{"allow": true}`)},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Timeout",
+			err:            errors.NewTimeout("query timed out"),
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "Evaluation error",
+			err:            errors.NewInternal("conflicting rules"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := openpolicyagent.NewPolicyClientFromEvaluator(evaluatorFunc(
+				func(_ context.Context, pkg string, rule string, _ interface{}) (*openpolicyagent.Decision, error) {
+					assert.Equal(t, "apps", pkg)
+					assert.Equal(t, "allow", rule)
+					return tc.decision, tc.err
+				}))
+
+			router := newEvaluatorAuthorizationRouter(t, client, WithBasePath("/api"))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/projects/p1/apps", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
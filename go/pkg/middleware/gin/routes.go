@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+type route struct {
+	method string
+	path   string
+}
+
+// routeMatcher matches requests against routes of the form "[METHOD ]path[*]", where path is
+// a gin path template and a trailing "*" matches all paths with that prefix
+type routeMatcher []route
+
+func (m routeMatcher) add(routes ...string) routeMatcher {
+	for _, r := range routes {
+		method, path, found := strings.Cut(strings.TrimSpace(r), " ")
+		if !found {
+			method, path = "", method
+		}
+		m = append(m, route{
+			method: strings.ToUpper(method),
+			path:   strings.TrimSpace(path),
+		})
+	}
+	return m
+}
+
+// match reports whether the request matches one of the routes. The gin path template is used
+// if the request matched a registered route, the request path otherwise.
+func (m routeMatcher) match(c *gin.Context) bool {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	for _, r := range m {
+		if r.method != "" && r.method != c.Request.Method {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(r.path, "*"); ok && strings.HasPrefix(path, prefix) || r.path == path {
+			return true
+		}
+	}
+	return false
+}
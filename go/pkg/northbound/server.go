@@ -48,6 +48,14 @@ type ServerConfig struct {
 	Port        int16
 	Insecure    bool
 	SecurityCfg *SecurityConfig
+	// Authenticators are tried in order to authenticate requests when SecurityCfg.AuthenticationEnabled
+	// is set; if empty the bearer token is validated with the environment configured auth.JwtAuthenticator
+	Authenticators []auth.RequestAuthenticator
+	// AuthExemptMethods are full gRPC method names, or prefixes ending with "*", that are neither
+	// authenticated nor authorized, e.g. auth.HealthCheckMethods
+	AuthExemptMethods []string
+	// Authorizer is used to authorize requests when SecurityCfg.AuthorizationEnabled is set
+	Authorizer *auth.OpaAuthorizer
 }
 
 // NewServer initializes gNMI server using the supplied configuration.
@@ -102,6 +110,9 @@ func (s *Server) AddService(r Service) {
 
 // Serve starts the NB gNMI server.
 func (s *Server) Serve(started func(string), grpcOpts ...grpc.ServerOption) error {
+	if s.cfg.SecurityCfg.AuthorizationEnabled && s.cfg.Authorizer == nil {
+		return fmt.Errorf("authorization is enabled but no authorizer is configured")
+	}
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.Port))
 	if err != nil {
 		return err
@@ -146,16 +157,28 @@ func (s *Server) Serve(started func(string), grpcOpts ...grpc.ServerOption) erro
 		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
 	}
 
+	var unaryInterceptors []grpc.UnaryServerInterceptor
+	var streamInterceptors []grpc.StreamServerInterceptor
 	if s.cfg.SecurityCfg.AuthenticationEnabled {
 		log.Info("Authentication Enabled")
-		opts = append(opts, grpc.UnaryInterceptor(
-			grpc_middleware.ChainUnaryServer(
-				grpc_auth.UnaryServerInterceptor(auth.AuthenticationInterceptor),
-			)))
-		opts = append(opts, grpc.StreamInterceptor(
-			grpc_middleware.ChainStreamServer(
-				grpc_auth.StreamServerInterceptor(auth.AuthenticationInterceptor))))
-
+		authFunc := auth.AuthenticationInterceptor
+		if len(s.cfg.Authenticators) > 0 {
+			authFunc = auth.NewChainAuthenticationInterceptor(s.cfg.Authenticators...)
+		}
+		authFunc = auth.ExemptMethods(authFunc, s.cfg.AuthExemptMethods...)
+		unaryInterceptors = append(unaryInterceptors, grpc_auth.UnaryServerInterceptor(authFunc))
+		streamInterceptors = append(streamInterceptors, grpc_auth.StreamServerInterceptor(authFunc))
+	}
+	if s.cfg.SecurityCfg.AuthorizationEnabled {
+		log.Info("Authorization Enabled")
+		unaryInterceptors = append(unaryInterceptors,
+			auth.ExemptUnaryServerInterceptor(s.cfg.Authorizer.UnaryServerInterceptor(), s.cfg.AuthExemptMethods...))
+		streamInterceptors = append(streamInterceptors,
+			auth.ExemptStreamServerInterceptor(s.cfg.Authorizer.StreamServerInterceptor(), s.cfg.AuthExemptMethods...))
+	}
+	if len(unaryInterceptors) > 0 {
+		opts = append(opts, grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(unaryInterceptors...)))
+		opts = append(opts, grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(streamInterceptors...)))
 	}
 
 	opts = append(opts, grpcOpts...)
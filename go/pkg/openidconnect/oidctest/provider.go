@@ -0,0 +1,385 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+// Package oidctest provides an in-process OpenID Connect provider for tests. It serves the discovery
+// document, the JWKS and a token endpoint, and mints signed tokens with arbitrary claims, including
+// expired tokens and tokens with invalid signatures for negative tests.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/open-edge-platform/orch-library/go/pkg/openidconnect"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTokenLifetime is the default lifetime of the issued tokens
+	DefaultTokenLifetime = 5 * time.Minute
+
+	wellKnownPath     = "/.well-known/openid-configuration"
+	jwksPath          = "/protocol/openid-connect/certs"
+	tokenPath         = "/protocol/openid-connect/token"
+	introspectionPath = "/protocol/openid-connect/token/introspect"
+)
+
+// Option configures a Provider
+type Option func(*Provider)
+
+// WithAlgorithm sets the signing algorithm of the provider keys: RS256 (the default), ES256 or EdDSA
+func WithAlgorithm(alg string) Option {
+	return func(p *Provider) {
+		p.alg = alg
+	}
+}
+
+// WithIssuerPath serves the provider below a path of the server, for example "/realms/master"
+// like Keycloak. The issuer is the server URL followed by the path.
+func WithIssuerPath(path string) Option {
+	return func(p *Provider) {
+		p.issuerPath = "/" + strings.Trim(path, "/")
+	}
+}
+
+// WithTokenLifetime sets the lifetime of the issued tokens
+func WithTokenLifetime(lifetime time.Duration) Option {
+	return func(p *Provider) {
+		p.lifetime = lifetime
+	}
+}
+
+// WithClient registers a confidential client for the client credentials grant of the token endpoint.
+// The claims are added to the tokens issued to its service account.
+func WithClient(clientID string, clientSecret string, claims jwt.MapClaims) Option {
+	return func(p *Provider) {
+		p.clients[clientID] = account{secret: clientSecret, claims: claims}
+	}
+}
+
+// WithUser registers a user for the password grant of the token endpoint.
+// The claims are added to the tokens issued to the user.
+func WithUser(username string, password string, claims jwt.MapClaims) Option {
+	return func(p *Provider) {
+		p.users[username] = account{secret: password, claims: claims}
+	}
+}
+
+type account struct {
+	secret string
+	claims jwt.MapClaims
+}
+
+type signingKey struct {
+	id      string
+	private crypto.Signer
+	method  jwt.SigningMethod
+}
+
+// Provider is an OpenID Connect provider served by an httptest server.
+// The first key of the JWKS signs new tokens; RotateKey adds a new signing key and keeps the
+// previous keys in the JWKS until RemoveKey, as identity providers do.
+type Provider struct {
+	server     *httptest.Server
+	alg        string
+	issuerPath string
+	lifetime   time.Duration
+	clients    map[string]account
+	users      map[string]account
+
+	mu      sync.Mutex
+	keys    []*signingKey
+	revoked map[string]bool
+}
+
+// NewProvider starts a provider with one signing key. Close it when the test is done.
+func NewProvider(opts ...Option) *Provider {
+	p := &Provider{
+		alg:      jwt.SigningMethodRS256.Alg(),
+		lifetime: DefaultTokenLifetime,
+		clients:  map[string]account{},
+		users:    map[string]account{},
+		revoked:  map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.keys = []*signingKey{p.newKey()}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+p.issuerPath+wellKnownPath, p.handleWellKnown)
+	mux.HandleFunc("GET "+p.issuerPath+jwksPath, p.handleJwks)
+	mux.HandleFunc("POST "+p.issuerPath+tokenPath, p.handleToken)
+	mux.HandleFunc("POST "+p.issuerPath+introspectionPath, p.handleIntrospection)
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+// Close shuts the provider down
+func (p *Provider) Close() {
+	p.server.Close()
+}
+
+// URL returns the base URL of the server
+func (p *Provider) URL() string {
+	return p.server.URL
+}
+
+// Issuer returns the issuer of the tokens, which is also the base URL of the discovery document
+func (p *Provider) Issuer() string {
+	return p.server.URL + p.issuerPath
+}
+
+// TokenURL returns the URL of the token endpoint
+func (p *Provider) TokenURL() string {
+	return p.Issuer() + tokenPath
+}
+
+// KeyIDs returns the IDs of the keys in the JWKS, the signing key first
+func (p *Provider) KeyIDs() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := make([]string, 0, len(p.keys))
+	for _, key := range p.keys {
+		ids = append(ids, key.id)
+	}
+	return ids
+}
+
+// RotateKey adds a new signing key to the JWKS and returns its ID. Previous keys stay in the JWKS.
+func (p *Provider) RotateKey() string {
+	key := p.newKey()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = append([]*signingKey{key}, p.keys...)
+	return key.id
+}
+
+// RemoveKey removes a key that is no longer signing from the JWKS, so that its tokens fail to verify
+func (p *Provider) RemoveKey(keyID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, key := range p.keys {
+		if key.id == keyID && i > 0 {
+			p.keys = append(p.keys[:i], p.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+// Revoke makes the introspection endpoint report the token as inactive
+func (p *Provider) Revoke(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.revoked[token] = true
+}
+
+// newKey generates a key for the signing algorithm of the provider
+func (p *Provider) newKey() *signingKey {
+	var private crypto.Signer
+	var err error
+	method := jwt.GetSigningMethod(p.alg)
+	switch method.(type) {
+	case *jwt.SigningMethodECDSA:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case *jwt.SigningMethodEd25519:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		panic(fmt.Sprintf("oidctest: unsupported signing algorithm %s", p.alg))
+	}
+	if err != nil {
+		panic(fmt.Sprintf("oidctest: unable to generate key: %v", err))
+	}
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return &signingKey{id: hex.EncodeToString(buf), private: private, method: method}
+}
+
+// IssueToken returns a token with the claims, signed with the current signing key.
+// The "iss", "iat", "exp" and "jti" claims are added unless the claims already have them.
+// IssueToken panics if the token cannot be signed.
+func (p *Provider) IssueToken(claims jwt.MapClaims, opts ...TokenOption) string {
+	cfg := &tokenConfig{issued: time.Now(), lifetime: p.lifetime}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.expired {
+		cfg.issued = time.Now().Add(-cfg.lifetime - time.Minute)
+	}
+
+	p.mu.Lock()
+	key := p.keys[0]
+	p.mu.Unlock()
+	keyID := key.id
+	if cfg.keyID != "" {
+		keyID = cfg.keyID
+	}
+	if cfg.forgeSignature {
+		key = p.newKey()
+	}
+
+	tokenClaims := jwt.MapClaims{
+		"iss": p.Issuer(),
+		"iat": cfg.issued.Unix(),
+		"exp": cfg.issued.Add(cfg.lifetime).Unix(),
+		"jti": randomID(),
+	}
+	for k, v := range claims {
+		tokenClaims[k] = v
+	}
+	token := jwt.NewWithClaims(key.method, tokenClaims)
+	token.Header["kid"] = keyID
+	signed, err := token.SignedString(key.private)
+	if err != nil {
+		panic(fmt.Sprintf("oidctest: unable to sign token: %v", err))
+	}
+	return signed
+}
+
+func randomID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (p *Provider) handleWellKnown(w http.ResponseWriter, _ *http.Request) {
+	issuer := p.Issuer()
+	jwksURI := issuer + jwksPath
+	tokenEndpoint := issuer + tokenPath
+	introspectionEndpoint := issuer + introspectionPath
+	writeJSON(w, http.StatusOK, openidconnect.WellKnownResponse{
+		Issuer:                           &issuer,
+		JwksUri:                          &jwksURI,
+		TokenEndpoint:                    &tokenEndpoint,
+		IntrospectionEndpoint:            &introspectionEndpoint,
+		GrantTypesSupported:              &[]string{"client_credentials", "password"},
+		ResponseTypesSupported:           &[]string{"code"},
+		SubjectTypesSupported:            &[]string{"public"},
+		IdTokenSigningAlgValuesSupported: &[]string{p.alg},
+		TokenEndpointAuthMethodsSupported: &[]string{
+			"client_secret_basic", "client_secret_post",
+		},
+	})
+}
+
+func (p *Provider) handleJwks(w http.ResponseWriter, _ *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keySet := jose.JSONWebKeySet{}
+	for _, key := range p.keys {
+		keySet.Keys = append(keySet.Keys, jose.JSONWebKey{
+			Key:       key.private.Public(),
+			KeyID:     key.id,
+			Algorithm: key.method.Alg(),
+			Use:       "sig",
+		})
+	}
+	writeJSON(w, http.StatusOK, keySet)
+}
+
+func writeTokenError(w http.ResponseWriter, status int, code string) {
+	tokenError := openidconnect.TokenErrorError(code)
+	writeJSON(w, status, openidconnect.TokenError{Error: &tokenError})
+}
+
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	clientID, clientSecret, basic := r.BasicAuth()
+	if !basic {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	claims := jwt.MapClaims{"azp": clientID, "typ": "Bearer"}
+	var extra jwt.MapClaims
+	switch r.PostForm.Get("grant_type") {
+	case "client_credentials":
+		client, ok := p.clients[clientID]
+		if !ok || client.secret != clientSecret {
+			writeTokenError(w, http.StatusUnauthorized, "invalid_client")
+			return
+		}
+		claims["sub"] = "service-account-" + clientID
+		claims["preferred_username"] = "service-account-" + clientID
+		claims["client_id"] = clientID
+		extra = client.claims
+	case "password":
+		username := r.PostForm.Get("username")
+		user, ok := p.users[username]
+		if !ok || user.secret != r.PostForm.Get("password") {
+			writeTokenError(w, http.StatusUnauthorized, "invalid_grant")
+			return
+		}
+		claims["sub"] = username
+		claims["preferred_username"] = username
+		extra = user.claims
+	default:
+		writeTokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	if scope := r.PostForm.Get("scope"); scope != "" {
+		claims["scope"] = scope
+	}
+
+	accessToken := p.IssueToken(claims)
+	expiresIn := int(p.lifetime.Seconds())
+	tokenType := "Bearer"
+	writeJSON(w, http.StatusOK, openidconnect.TokenResponse{
+		AccessToken: &accessToken,
+		ExpiresIn:   &expiresIn,
+		TokenType:   &tokenType,
+	})
+}
+
+// handleIntrospection reports whether a token issued by the provider is active, without client authentication
+func (p *Provider) handleIntrospection(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	p.mu.Lock()
+	revoked := p.revoked[token]
+	p.mu.Unlock()
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.NewParser(jwt.WithIssuer(p.Issuer())).ParseWithClaims(token, claims, p.verificationKey)
+	if err != nil || revoked {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"active": false})
+		return
+	}
+	claims["active"] = true
+	writeJSON(w, http.StatusOK, claims)
+}
+
+func (p *Provider) verificationKey(token *jwt.Token) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, key := range p.keys {
+		if key.id == token.Header["kid"] && key.method.Alg() == token.Method.Alg() {
+			return key.private.Public(), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key ID %v", token.Header["kid"])
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package oidctest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/open-edge-platform/orch-library/go/pkg/auth"
+	"github.com/open-edge-platform/orch-library/go/pkg/openidconnect"
+	"github.com/open-edge-platform/orch-library/go/pkg/openidconnect/oidctest"
+	"gotest.tools/assert"
+)
+
+func TestProvider_IssueToken(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			p := oidctest.NewProvider(oidctest.WithAlgorithm(alg), oidctest.WithIssuerPath("/realms/master"))
+			defer p.Close()
+			authenticator := auth.NewJwtAuthenticator(auth.WithIssuer(p.Issuer()))
+
+			claims, err := authenticator.Authenticate(p.IssueToken(jwt.MapClaims{"sub": "user1"}))
+			assert.NilError(t, err)
+			assert.Equal(t, "user1", claims["sub"])
+			assert.Equal(t, p.Issuer(), claims["iss"])
+
+			_, err = authenticator.Authenticate(p.IssueToken(jwt.MapClaims{"sub": "user1"}, oidctest.Expired()))
+			assert.ErrorContains(t, err, "expired")
+			_, err = authenticator.Authenticate(p.IssueToken(jwt.MapClaims{"sub": "user1"}, oidctest.InvalidSignature()))
+			assert.ErrorContains(t, err, "signature is invalid")
+			_, err = authenticator.Authenticate(p.IssueToken(jwt.MapClaims{"iss": "https://other"}))
+			assert.ErrorContains(t, err, "issuer")
+
+			// Tokens issued in the past are valid until their own expiry
+			_, err = authenticator.Authenticate(p.IssueToken(jwt.MapClaims{"sub": "user1"},
+				oidctest.WithIssuedAt(time.Now().Add(-time.Hour)), oidctest.WithLifetime(2*time.Hour)))
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestProvider_KeyRotation(t *testing.T) {
+	p := oidctest.NewProvider()
+	defer p.Close()
+	keySet := auth.NewJwksKeySet(p.Issuer(), nil, auth.WithJwksMinRefreshInterval(0))
+	authenticator := auth.NewJwtAuthenticator(auth.WithIssuer(p.Issuer()), auth.WithKeySet(keySet))
+
+	oldKey := p.KeyIDs()[0]
+	oldToken := p.IssueToken(jwt.MapClaims{"sub": "user1"})
+	_, err := authenticator.Authenticate(oldToken)
+	assert.NilError(t, err)
+
+	newKey := p.RotateKey()
+	assert.DeepEqual(t, []string{newKey, oldKey}, p.KeyIDs())
+	_, err = authenticator.Authenticate(p.IssueToken(jwt.MapClaims{"sub": "user1"}))
+	assert.NilError(t, err)
+	_, err = authenticator.Authenticate(oldToken)
+	assert.NilError(t, err)
+
+	p.RemoveKey(oldKey)
+	assert.NilError(t, keySet.Refresh())
+	_, err = authenticator.Authenticate(oldToken)
+	assert.Assert(t, err != nil)
+	_, err = authenticator.Authenticate(p.IssueToken(jwt.MapClaims{"sub": "user1"}, oidctest.WithKeyID(oldKey)))
+	assert.Assert(t, err != nil)
+}
+
+func requestToken(t *testing.T, p *oidctest.Provider, form url.Values) (int, *openidconnect.TokenResponse) {
+	resp, err := http.Post(p.TokenURL(), "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	tokenResp := &openidconnect.TokenResponse{}
+	assert.NilError(t, json.NewDecoder(resp.Body).Decode(tokenResp))
+	return resp.StatusCode, tokenResp
+}
+
+func TestProvider_TokenEndpoint(t *testing.T) {
+	p := oidctest.NewProvider(
+		oidctest.WithClient("app-m2m", "secret", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []string{"app-read-role"}}}),
+		oidctest.WithUser("alice", "pass", jwt.MapClaims{"email": "alice@example.com"}))
+	defer p.Close()
+	authenticator := auth.NewJwtAuthenticator(auth.WithIssuer(p.Issuer()))
+
+	status, tokenResp := requestToken(t, p, url.Values{
+		"grant_type": {"client_credentials"}, "client_id": {"app-m2m"}, "client_secret": {"secret"},
+	})
+	assert.Equal(t, http.StatusOK, status)
+	claims, err := authenticator.Authenticate(*tokenResp.AccessToken)
+	assert.NilError(t, err)
+	principal := auth.NewPrincipal(claims)
+	assert.Assert(t, principal.IsM2M())
+	assert.Assert(t, principal.HasRole("app-read-role"))
+
+	status, tokenResp = requestToken(t, p, url.Values{
+		"grant_type": {"password"}, "client_id": {"cli"}, "username": {"alice"}, "password": {"pass"},
+	})
+	assert.Equal(t, http.StatusOK, status)
+	claims, err = authenticator.Authenticate(*tokenResp.AccessToken)
+	assert.NilError(t, err)
+	assert.Equal(t, "alice@example.com", auth.NewPrincipal(claims).Email)
+
+	status, _ = requestToken(t, p, url.Values{
+		"grant_type": {"client_credentials"}, "client_id": {"app-m2m"}, "client_secret": {"wrong"},
+	})
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestProvider_Introspection(t *testing.T) {
+	p := oidctest.NewProvider()
+	defer p.Close()
+	authenticator, err := auth.NewIntrospectionAuthenticator("client", "secret",
+		auth.WithIntrospectionDiscoveryURL(p.Issuer()), auth.WithIntrospectionCacheSize(0))
+	assert.NilError(t, err)
+
+	token := p.IssueToken(jwt.MapClaims{"sub": "user1"})
+	claims, err := authenticator.Authenticate(token)
+	assert.NilError(t, err)
+	assert.Equal(t, "user1", claims["sub"])
+
+	p.Revoke(token)
+	_, err = authenticator.Authenticate(token)
+	assert.ErrorContains(t, err, "not active")
+	_, err = authenticator.Authenticate(p.IssueToken(jwt.MapClaims{"sub": "user1"}, oidctest.InvalidSignature()))
+	assert.ErrorContains(t, err, "not active")
+}
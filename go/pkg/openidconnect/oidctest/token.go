@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package oidctest
+
+import (
+	"time"
+)
+
+// TokenOption configures a token minted by IssueToken
+type TokenOption func(*tokenConfig)
+
+type tokenConfig struct {
+	issued         time.Time
+	lifetime       time.Duration
+	keyID          string
+	expired        bool
+	forgeSignature bool
+}
+
+// WithLifetime sets the lifetime of the token instead of the lifetime of the provider
+func WithLifetime(lifetime time.Duration) TokenOption {
+	return func(c *tokenConfig) {
+		c.lifetime = lifetime
+	}
+}
+
+// WithIssuedAt sets when the token was issued; its expiry is the issue time plus its lifetime
+func WithIssuedAt(issued time.Time) TokenOption {
+	return func(c *tokenConfig) {
+		c.issued = issued
+	}
+}
+
+// Expired mints a token that expired a minute ago
+func Expired() TokenOption {
+	return func(c *tokenConfig) {
+		c.expired = true
+	}
+}
+
+// WithKeyID sets the "kid" header of the token, for example to the ID of a removed key or an unknown ID.
+// The token is still signed with the current signing key.
+func WithKeyID(keyID string) TokenOption {
+	return func(c *tokenConfig) {
+		c.keyID = keyID
+	}
+}
+
+// InvalidSignature signs the token with a throwaway key while keeping the "kid" header of the
+// signing key, so that the signature does not verify with the key the token points to
+func InvalidSignature() TokenOption {
+	return func(c *tokenConfig) {
+		c.forgeSignature = true
+	}
+}
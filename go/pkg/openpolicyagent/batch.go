@@ -0,0 +1,285 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package openpolicyagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultBatchConcurrency is the default number of concurrent single queries
+// used to evaluate a batch when the evaluator has no batch support
+const DefaultBatchConcurrency = 8
+
+// BatchEvaluator is implemented by evaluators that evaluate a rule against many inputs at once.
+// EvaluateBatch returns a NotSupported error if batches cannot be evaluated, e.g. because the
+// server has no batch API, in which case the PolicyClient falls back to single queries.
+type BatchEvaluator interface {
+	EvaluateBatch(ctx context.Context, pkg string, rule string, inputs []interface{}) ([]*Decision, error)
+}
+
+// WithBatchConcurrency sets the number of concurrent single queries used to evaluate a batch
+// when the evaluator has no batch support
+func WithBatchConcurrency(concurrency int) PolicyClientOption {
+	return func(c *PolicyClient) {
+		c.batchConcurrency = concurrency
+	}
+}
+
+// EvaluateBatch evaluates the rule against each input, returning the decisions in the order of the
+// inputs. Cached decisions are reused and the rest are evaluated in one batch if the evaluator supports
+// it, or else with concurrent single queries. The batch fails if the evaluation of any input fails.
+func (c *PolicyClient) EvaluateBatch(ctx context.Context, pkg string, rule string, inputs []interface{}) ([]*Decision, error) {
+	decisions := make([]*Decision, len(inputs))
+	keys := make([]string, len(inputs))
+	var pending []int
+	for i, input := range inputs {
+		if c.cache != nil {
+			key, err := decisionKey(pkg, rule, input)
+			if err != nil {
+				return nil, errors.NewInvalid("unable to encode input %d of %s/%s: %v", i, pkg, rule, err)
+			}
+			keys[i] = key
+			if decision, ok := c.cache.get(key); ok {
+				decisions[i] = decision
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		c.observeBatch(ctx, pkg, rule, decisions)
+		return decisions, nil
+	}
+
+	pendingInputs := make([]interface{}, len(pending))
+	for j, i := range pending {
+		pendingInputs[j] = inputs[i]
+	}
+	evaluated, err := c.evaluateBatch(ctx, pkg, rule, pendingInputs)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range pending {
+		decisions[i] = evaluated[j]
+		if c.cache != nil {
+			c.cache.put(keys[i], evaluated[j])
+		}
+	}
+	c.observeBatch(ctx, pkg, rule, decisions)
+	return decisions, nil
+}
+
+// AllowBatch evaluates a boolean rule against each input, returning the results in the order of the inputs.
+// An undefined rule is a deny; a rule of another type is an Invalid error.
+func (c *PolicyClient) AllowBatch(ctx context.Context, pkg string, rule string, inputs []interface{}) ([]bool, error) {
+	decisions, err := c.EvaluateBatch(ctx, pkg, rule, inputs)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make([]bool, len(decisions))
+	for i, decision := range decisions {
+		if !decision.Defined() {
+			continue
+		}
+		if err := json.Unmarshal(decision.Result, &allowed[i]); err != nil {
+			return nil, errors.NewInvalid("result of %s/%s for input %d is not a boolean", pkg, rule, i)
+		}
+	}
+	return allowed, nil
+}
+
+func (c *PolicyClient) evaluateBatch(ctx context.Context, pkg string, rule string, inputs []interface{}) ([]*Decision, error) {
+	if batchEvaluator, ok := c.evaluator.(BatchEvaluator); ok {
+		decisions, err := batchEvaluator.EvaluateBatch(ctx, pkg, rule, inputs)
+		if !errors.IsNotSupported(err) {
+			return decisions, err
+		}
+		log.Debugf("Batch queries not supported, evaluating %d inputs of %s/%s one by one", len(inputs), pkg, rule)
+	}
+
+	concurrency := c.batchConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	decisions := make([]*Decision, len(inputs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, input := range inputs {
+		g.Go(func() error {
+			decision, err := c.evaluator.Evaluate(gctx, pkg, rule, input)
+			if err != nil {
+				return err
+			}
+			decisions[i] = decision
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+func (c *PolicyClient) observeBatch(ctx context.Context, pkg string, rule string, decisions []*Decision) {
+	for _, decision := range decisions {
+		c.observe(ctx, pkg, rule, decision)
+	}
+}
+
+var _ BatchEvaluator = &ServerEvaluator{}
+
+// ServerEvaluatorOption configures a ServerEvaluator
+type ServerEvaluatorOption func(*ServerEvaluator)
+
+// WithQueryClient enables batch queries with the query API, /v1/query, of the OPA server of the client.
+// The requests are sent with the HTTP client and the request editors of the client.
+func WithQueryClient(client *Client) ServerEvaluatorOption {
+	return func(e *ServerEvaluator) {
+		e.queryClient = client
+	}
+}
+
+type queryRequest struct {
+	Query string                 `json:"query"`
+	Input map[string]interface{} `json:"input"`
+}
+
+type queryResponse struct {
+	Result []struct {
+		Decisions map[string]json.RawMessage `json:"decisions"`
+	} `json:"result"`
+}
+
+// EvaluateBatch evaluates the rule against all inputs in one query of the query API, which is served
+// by every OPA server. The query binds each input in turn with a comprehension, so an input for which
+// the rule is undefined has an undefined decision. The query API writes no decision log, so the
+// decisions have no ID. A NotSupported error is returned if no query client was set with
+// WithQueryClient, or if the server has no query API; the latter is remembered for later batches.
+func (e *ServerEvaluator) EvaluateBatch(ctx context.Context, pkg string, rule string, inputs []interface{}) ([]*Decision, error) {
+	if e.queryClient == nil || e.batchUnsupported.Load() {
+		return nil, errors.NewNotSupported("batch queries are not supported by the OPA client")
+	}
+
+	items := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		opaInput, err := toInput(input)
+		if err != nil {
+			return nil, errors.NewInvalid("unable to encode input %d of %s/%s: %v", i, pkg, rule, err)
+		}
+		items[i] = opaInput.Input
+	}
+	query, err := batchQuery(pkg, rule)
+	if err != nil {
+		return nil, errors.NewInvalid("unable to create batch query of %s/%s: %v", pkg, rule, err)
+	}
+	req, err := newQueryRequest(ctx, e.queryClient, queryRequest{
+		Query: query,
+		Input: map[string]interface{}{"items": items},
+	})
+	if err != nil {
+		return nil, errors.NewInvalid("unable to create batch query of %s/%s: %v", pkg, rule, err)
+	}
+
+	resp, err := e.queryClient.Client.Do(req)
+	if err != nil {
+		switch ctx.Err() {
+		case context.Canceled:
+			return nil, errors.NewCanceled("batch query of %s/%s canceled", pkg, rule)
+		case context.DeadlineExceeded:
+			return nil, errors.NewTimeout("batch query of %s/%s timed out", pkg, rule)
+		}
+		return nil, errors.NewUnavailable("unable to query %s/%s: %v", pkg, rule, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.NewUnavailable("unable to read batch result of %s/%s: %v", pkg, rule, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		e.batchUnsupported.Store(true)
+		log.Infof("OPA server has no query API (%d), falling back to single queries", resp.StatusCode)
+		return nil, errors.NewNotSupported("batch queries are not supported by the OPA server")
+	default:
+		parsed := &PostV1DataPackageRuleResponse{Body: data, HTTPResponse: resp}
+		var opaErr OpaError
+		if json.Unmarshal(data, &opaErr) == nil {
+			parsed.JSONDefault = &opaErr
+		}
+		return nil, responseError(parsed, fmt.Sprintf("batch query of %s/%s failed", pkg, rule))
+	}
+
+	var result queryResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, errors.NewInternal("unable to decode batch result of %s/%s: %v", pkg, rule, err)
+	}
+	if len(result.Result) != 1 {
+		return nil, errors.NewInternal("batch result of %s/%s has %d solutions", pkg, rule, len(result.Result))
+	}
+	decisions := make([]*Decision, len(inputs))
+	for i := range inputs {
+		// Inputs for which the rule is undefined have no key in the object
+		decisions[i] = &Decision{Result: result.Result[0].Decisions[strconv.Itoa(i)]}
+	}
+	return decisions, nil
+}
+
+// batchQuery returns the Rego query evaluating the rule against each of input.items, binding
+// decisions to an object of the results by index, e.g.
+// decisions := {i: r | some i; x := input.items[i]; r := data["catalog"]["allow"] with input as x}
+func batchQuery(pkg string, rule string) (string, error) {
+	var ref strings.Builder
+	ref.WriteString("data")
+	for _, name := range append(strings.Split(strings.Trim(pkg, "/"), "/"), rule) {
+		if name == "" {
+			return "", fmt.Errorf("empty element in %s/%s", pkg, rule)
+		}
+		quoted, err := json.Marshal(name)
+		if err != nil {
+			return "", err
+		}
+		ref.WriteString("[")
+		ref.Write(quoted)
+		ref.WriteString("]")
+	}
+	return fmt.Sprintf("decisions := {i: r | some i; x := input.items[i]; r := %s with input as x}", ref.String()), nil
+}
+
+// newQueryRequest creates the request to the query API in the way of the generated request functions
+func newQueryRequest(ctx context.Context, client *Client, body queryRequest) (*http.Request, error) {
+	serverURL, err := url.Parse(client.Server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err := serverURL.Parse("./v1/query")
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, queryURL.String(), bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if err := client.applyEditors(ctx, req, nil); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
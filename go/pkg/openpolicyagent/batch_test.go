@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package openpolicyagent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"gotest.tools/assert"
+)
+
+// newBatchTestServer serves the data API and, if query is true, the query API.
+// Inputs with the user "alice" are allowed, inputs with the user "carol" are undefined
+// and inputs with the user "error" fail.
+func newBatchTestServer(t *testing.T, query bool) (*Client, *atomic.Int32, *atomic.Int32) {
+	queryCalls, singleCalls := &atomic.Int32{}, &atomic.Int32{}
+	decide := func(input map[string]interface{}) (int, string) {
+		switch input["user"] {
+		case "alice":
+			return http.StatusOK, `{"decision_id": "d-alice", "result": true}`
+		case "carol":
+			return http.StatusOK, `{"decision_id": "d-carol"}`
+		case "error":
+			return http.StatusInternalServerError, `{"code": "internal_error", "message": "eval_conflict_error"}`
+		}
+		return http.StatusOK, `{"decision_id": "d-other", "result": false}`
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/data/catalog/allow", func(w http.ResponseWriter, r *http.Request) {
+		singleCalls.Add(1)
+		var body OpaInput
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+		statusCode, response := decide(body.Input)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(response))
+	})
+	if query {
+		mux.HandleFunc("POST /v1/query", func(w http.ResponseWriter, r *http.Request) {
+			queryCalls.Add(1)
+			var body struct {
+				Query string `json:"query"`
+				Input struct {
+					Items []map[string]interface{} `json:"items"`
+				} `json:"input"`
+			}
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, `decisions := {i: r | some i; x := input.items[i]; r := data["catalog"]["allow"] with input as x}`, body.Query)
+			w.Header().Set("Content-Type", "application/json")
+			decisions := make(map[string]json.RawMessage, len(body.Input.Items))
+			for i, input := range body.Input.Items {
+				statusCode, response := decide(input)
+				if statusCode != http.StatusOK {
+					w.WriteHeader(statusCode)
+					_, _ = w.Write([]byte(response))
+					return
+				}
+				var decision struct {
+					Result json.RawMessage `json:"result"`
+				}
+				assert.NilError(t, json.Unmarshal([]byte(response), &decision))
+				if decision.Result != nil {
+					decisions[strconv.Itoa(i)] = decision.Result
+				}
+			}
+			assert.NilError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"result": []interface{}{map[string]interface{}{"decisions": decisions}},
+			}))
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL)
+	assert.NilError(t, err)
+	return client, queryCalls, singleCalls
+}
+
+func batchInputs(users ...string) []interface{} {
+	inputs := make([]interface{}, len(users))
+	for i, user := range users {
+		inputs[i] = testInput{User: user}
+	}
+	return inputs
+}
+
+func TestPolicyClientAllowBatch(t *testing.T) {
+	testCases := []struct {
+		name        string
+		queryAPI    bool
+		queryClient bool
+	}{
+		{name: "query API", queryAPI: true, queryClient: true},
+		{name: "no query API", queryAPI: false, queryClient: true},
+		{name: "no query client", queryAPI: true, queryClient: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opaClient, queryCalls, singleCalls := newBatchTestServer(t, tc.queryAPI)
+			var opts []ServerEvaluatorOption
+			if tc.queryClient {
+				opts = append(opts, WithQueryClient(opaClient))
+			}
+			client := NewPolicyClientFromEvaluator(NewServerEvaluator(&ClientWithResponses{opaClient}, opts...))
+			ctx := context.Background()
+
+			allowed, err := client.AllowBatch(ctx, "catalog", "allow", batchInputs("alice", "bob", "alice", "carol"))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []bool{true, false, true, false}, allowed)
+
+			decisions, err := client.EvaluateBatch(ctx, "catalog", "allow", batchInputs("bob", "carol", "alice"))
+			assert.NilError(t, err)
+			assert.Assert(t, decisions[0].Defined())
+			assert.Assert(t, !decisions[1].Defined())
+			assert.Assert(t, decisions[2].Allowed())
+
+			switch {
+			case tc.queryAPI && tc.queryClient:
+				assert.Equal(t, int32(2), queryCalls.Load())
+				assert.Equal(t, int32(0), singleCalls.Load())
+			case tc.queryClient:
+				// The missing query API is only probed once
+				assert.Equal(t, int32(7), singleCalls.Load())
+			default:
+				assert.Equal(t, int32(0), queryCalls.Load())
+				assert.Equal(t, int32(7), singleCalls.Load())
+			}
+
+			_, err = client.AllowBatch(ctx, "catalog", "allow", batchInputs("alice", "error"))
+			assert.Assert(t, errors.IsInternal(err), err)
+
+			allowed, err = client.AllowBatch(ctx, "catalog", "allow", nil)
+			assert.NilError(t, err)
+			assert.Equal(t, 0, len(allowed))
+		})
+	}
+}
+
+func TestBatchQuery(t *testing.T) {
+	query, err := batchQuery("catalog/v1", "allow")
+	assert.NilError(t, err)
+	assert.Equal(t, `decisions := {i: r | some i; x := input.items[i]; r := data["catalog"]["v1"]["allow"] with input as x}`, query)
+
+	query, err = batchQuery("catalog", `a"b`)
+	assert.NilError(t, err)
+	assert.Equal(t, `decisions := {i: r | some i; x := input.items[i]; r := data["catalog"]["a\"b"] with input as x}`, query)
+
+	_, err = batchQuery("catalog//v1", "allow")
+	assert.ErrorContains(t, err, "empty element")
+}
+
+func TestPolicyClientBatchDecisionCache(t *testing.T) {
+	opaClient, batchCalls, _ := newBatchTestServer(t, true)
+	client := NewPolicyClientFromEvaluator(NewServerEvaluator(&ClientWithResponses{opaClient}, WithQueryClient(opaClient)),
+		WithDecisionCache(10, time.Minute))
+	ctx := context.Background()
+
+	_, err := client.Allow(ctx, "catalog", "allow", testInput{User: "alice"})
+	assert.NilError(t, err)
+
+	decisions, err := client.EvaluateBatch(ctx, "catalog", "allow", batchInputs("alice", "bob"))
+	assert.NilError(t, err)
+	assert.Assert(t, decisions[0].Cached)
+	assert.Assert(t, !decisions[1].Cached)
+	assert.Equal(t, int32(1), batchCalls.Load())
+
+	decisions, err = client.EvaluateBatch(ctx, "catalog", "allow", batchInputs("alice", "bob"))
+	assert.NilError(t, err)
+	assert.Assert(t, decisions[0].Cached && decisions[1].Cached)
+	assert.Equal(t, int32(1), batchCalls.Load())
+}
+
+// testEvaluator allows the user "alice" and records the maximum number of concurrent evaluations
+type testEvaluator struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (e *testEvaluator) Evaluate(_ context.Context, _ string, _ string, input interface{}) (*Decision, error) {
+	e.mu.Lock()
+	e.inFlight++
+	if e.inFlight > e.max {
+		e.max = e.inFlight
+	}
+	e.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	e.mu.Lock()
+	e.inFlight--
+	e.mu.Unlock()
+	return &Decision{Result: json.RawMessage(fmt.Sprintf("%t", input.(testInput).User == "alice"))}, nil
+}
+
+func TestPolicyClientBatchConcurrency(t *testing.T) {
+	evaluator := &testEvaluator{}
+	client := NewPolicyClientFromEvaluator(evaluator, WithBatchConcurrency(3))
+
+	users := make([]string, 20)
+	for i := range users {
+		users[i] = fmt.Sprintf("user-%d", i)
+	}
+	users[7] = "alice"
+	allowed, err := client.AllowBatch(context.Background(), "catalog", "allow", batchInputs(users...))
+	assert.NilError(t, err)
+	for i, a := range allowed {
+		assert.Equal(t, i == 7, a)
+	}
+	assert.Assert(t, evaluator.max <= 3, evaluator.max)
+	assert.Assert(t, evaluator.max > 1, evaluator.max)
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package openpolicyagent
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+const (
+	// PolicyModeSidecar queries an OPA server, usually a sidecar, over its REST API
+	PolicyModeSidecar = "sidecar"
+	// PolicyModeEmbedded evaluates the Rego bundle in-process
+	PolicyModeEmbedded = "embedded"
+)
+
+const (
+	// RegoV0 is the Rego syntax of OPA before 1.0, without the if and contains keywords
+	RegoV0 = "v0"
+	// RegoV1 is the Rego syntax of OPA 1.0 and later
+	RegoV1 = "v1"
+)
+
+// PolicyConfig selects where the policies of a PolicyClient are evaluated
+type PolicyConfig struct {
+	// Mode is PolicyModeSidecar or PolicyModeEmbedded; sidecar if empty
+	Mode string
+	// ServerURL is the URL of the OPA server in sidecar mode, e.g. http://localhost:8181
+	ServerURL string
+	// BundleDir is the directory of the Rego bundle in embedded mode
+	BundleDir string
+	// BundleFS is the file system of the Rego bundle in embedded mode, e.g. an embed.FS;
+	// it is used instead of BundleDir if set
+	BundleFS fs.FS
+	// BundleRoot is the path of the bundle in BundleFS; the root of BundleFS if empty
+	BundleRoot string
+	// ReloadInterval is how often a bundle is checked for changes in embedded mode;
+	// the default of the embedded module if zero, no reload if negative
+	ReloadInterval time.Duration
+	// RegoVersion is RegoV0 or RegoV1, the syntax of the modules of the bundle in embedded mode;
+	// RegoV1 if empty. The rego_version of the bundle manifest takes precedence.
+	RegoVersion string
+}
+
+// NewPolicyClientFromConfig creates a policy client querying the OPA server of the config. Embedded mode
+// is served by NewPolicyClientFromConfig of the openpolicyagent/embedded module, so that services
+// querying a sidecar do not link the OPA runtime.
+func NewPolicyClientFromConfig(cfg PolicyConfig, opts ...PolicyClientOption) (*PolicyClient, error) {
+	switch cfg.Mode {
+	case "", PolicyModeSidecar:
+		if cfg.ServerURL == "" {
+			return nil, fmt.Errorf("OPA server URL is required in %s mode", PolicyModeSidecar)
+		}
+		client, err := NewClient(cfg.ServerURL)
+		if err != nil {
+			return nil, err
+		}
+		evaluator := NewServerEvaluator(&ClientWithResponses{ClientInterface: client}, WithQueryClient(client))
+		return NewPolicyClientFromEvaluator(evaluator, opts...), nil
+	case PolicyModeEmbedded:
+		return nil, fmt.Errorf("%s mode requires the openpolicyagent/embedded module", PolicyModeEmbedded)
+	}
+	return nil, fmt.Errorf("unknown OPA policy mode %q", cfg.Mode)
+}
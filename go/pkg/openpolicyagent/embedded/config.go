@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"github.com/open-edge-platform/orch-library/go/pkg/openpolicyagent"
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+// NewPolicyClientFromConfig creates a policy client evaluating policies in the mode of the config,
+// either with the OPA server or in-process. In embedded mode the bundle is reloaded on changes
+// until the context is done.
+func NewPolicyClientFromConfig(ctx context.Context, cfg openpolicyagent.PolicyConfig,
+	opts ...openpolicyagent.PolicyClientOption) (*openpolicyagent.PolicyClient, error) {
+	if cfg.Mode != openpolicyagent.PolicyModeEmbedded {
+		return openpolicyagent.NewPolicyClientFromConfig(cfg, opts...)
+	}
+
+	var embeddedOpts []Option
+	if cfg.ReloadInterval > 0 {
+		embeddedOpts = append(embeddedOpts, WithReloadInterval(cfg.ReloadInterval))
+	}
+	switch cfg.RegoVersion {
+	case "", openpolicyagent.RegoV1:
+		embeddedOpts = append(embeddedOpts, WithRegoVersion(ast.RegoV1))
+	case openpolicyagent.RegoV0:
+		embeddedOpts = append(embeddedOpts, WithRegoVersion(ast.RegoV0))
+	default:
+		return nil, fmt.Errorf("unknown Rego version %q", cfg.RegoVersion)
+	}
+	var (
+		evaluator *Evaluator
+		err       error
+	)
+	switch {
+	case cfg.BundleFS != nil:
+		root := cfg.BundleRoot
+		if root == "" {
+			root = "."
+		}
+		evaluator, err = NewEvaluator(cfg.BundleFS, root, embeddedOpts...)
+	case cfg.BundleDir != "":
+		evaluator, err = NewEvaluatorFromDir(cfg.BundleDir, embeddedOpts...)
+	default:
+		return nil, fmt.Errorf("a bundle directory or file system is required in %s mode", openpolicyagent.PolicyModeEmbedded)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ReloadInterval >= 0 {
+		evaluator.Start(ctx)
+	}
+	return openpolicyagent.NewPolicyClientFromEvaluator(evaluator, opts...), nil
+}
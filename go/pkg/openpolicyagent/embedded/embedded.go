@@ -0,0 +1,279 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+// Package embedded evaluates the Rego policies of a bundle in-process, without an OPA server. It is a
+// module of its own, so that services querying an OPA sidecar do not link the OPA runtime.
+package embedded
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/open-edge-platform/orch-library/go/dazl"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"github.com/open-edge-platform/orch-library/go/pkg/openpolicyagent"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/loader"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/storage"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = dazl.GetLogger()
+
+// DefaultReloadInterval is how often the bundle is checked for changes
+const DefaultReloadInterval = 10 * time.Second
+
+// Option configures an Evaluator
+type Option func(*Evaluator)
+
+// WithReloadInterval sets how often Start checks the bundle for changes
+func WithReloadInterval(interval time.Duration) Option {
+	return func(e *Evaluator) {
+		e.reloadInterval = interval
+	}
+}
+
+// WithRegoVersion sets the Rego syntax the modules of the bundle are parsed with, ast.RegoV1 by
+// default. Bundles written for OPA before 1.0, without the if and contains keywords, need ast.RegoV0.
+// The rego_version of the bundle manifest takes precedence.
+func WithRegoVersion(version ast.RegoVersion) Option {
+	return func(e *Evaluator) {
+		e.regoVersion = version
+	}
+}
+
+// policySet is one loaded version of the bundle with the queries prepared for it
+type policySet struct {
+	fingerprint string
+	compiler    *ast.Compiler
+	store       storage.Store
+
+	mu      sync.Mutex
+	queries map[string]*rego.PreparedEvalQuery
+}
+
+// Evaluator evaluates the Rego policies of a bundle in-process, without an OPA server.
+// The bundle is a directory tree of .rego modules and data.json or data.yaml documents, as
+// built for an OPA server. It is safe for concurrent use.
+type Evaluator struct {
+	fsys           fs.FS
+	root           string
+	reloadInterval time.Duration
+	regoVersion    ast.RegoVersion
+
+	mu       sync.RWMutex
+	policies *policySet
+}
+
+var _ openpolicyagent.Evaluator = &Evaluator{}
+
+// NewEvaluator loads the bundle at root in fsys, e.g. an embed.FS
+func NewEvaluator(fsys fs.FS, root string, opts ...Option) (*Evaluator, error) {
+	e := &Evaluator{
+		fsys:           fsys,
+		root:           root,
+		reloadInterval: DefaultReloadInterval,
+		regoVersion:    ast.RegoV1,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// NewEvaluatorFromDir loads the bundle in the directory dir
+func NewEvaluatorFromDir(dir string, opts ...Option) (*Evaluator, error) {
+	return NewEvaluator(os.DirFS(dir), ".", opts...)
+}
+
+// Start reloads the bundle in the background when its files change, until the context is done.
+// A bundle that fails to load is logged and the policies loaded before are kept.
+func (e *Evaluator) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(e.reloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := e.Reload(); err != nil {
+					log.Warnf("unable to reload OPA bundle %s", err)
+				}
+			}
+		}
+	}()
+}
+
+// Reload loads the bundle if its files changed since it was last loaded
+func (e *Evaluator) Reload() error {
+	fingerprint, err := bundleFingerprint(e.fsys, e.root)
+	if err != nil {
+		return fmt.Errorf("unable to read OPA bundle: %w", err)
+	}
+	e.mu.RLock()
+	current := e.policies
+	e.mu.RUnlock()
+	if current != nil && current.fingerprint == fingerprint {
+		return nil
+	}
+
+	policies, err := loadPolicies(e.fsys, e.root, e.regoVersion)
+	if err != nil {
+		return err
+	}
+	policies.fingerprint = fingerprint
+
+	e.mu.Lock()
+	e.policies = policies
+	e.mu.Unlock()
+	log.Infof("Loaded OPA bundle with %d modules", len(policies.compiler.Modules))
+	return nil
+}
+
+// Evaluate evaluates the rule of the package against the input. Packages may be separated by
+// slashes as in the data API of an OPA server, e.g. "catalog/v1", or by dots.
+func (e *Evaluator) Evaluate(ctx context.Context, pkg string, rule string, input interface{}) (*openpolicyagent.Decision, error) {
+	evalInput, err := toInput(input)
+	if err != nil {
+		return nil, errors.NewInvalid("unable to encode input of %s/%s: %v", pkg, rule, err)
+	}
+
+	e.mu.RLock()
+	policies := e.policies
+	e.mu.RUnlock()
+
+	query, err := policies.prepare(ctx, pkg, rule)
+	if err != nil {
+		return nil, errors.NewInvalid("unable to prepare query of %s/%s: %v", pkg, rule, err)
+	}
+	rs, err := query.Eval(ctx, rego.EvalInput(evalInput))
+	if err != nil {
+		switch ctx.Err() {
+		case context.Canceled:
+			return nil, errors.NewCanceled("query of %s/%s canceled", pkg, rule)
+		case context.DeadlineExceeded:
+			return nil, errors.NewTimeout("query of %s/%s timed out", pkg, rule)
+		}
+		return nil, errors.NewInternal("query of %s/%s failed: %v", pkg, rule, err)
+	}
+
+	// Embedded decisions are not in an OPA decision log, but still get an ID for audit logs
+	decision := &openpolicyagent.Decision{ID: uuid.NewString()}
+	if len(rs) > 0 && len(rs[0].Expressions) > 0 {
+		decision.Result, err = json.Marshal(rs[0].Expressions[0].Value)
+		if err != nil {
+			return nil, errors.NewInternal("unable to encode result of %s/%s: %v", pkg, rule, err)
+		}
+	}
+	return decision, nil
+}
+
+// toInput converts the input to the generic map evaluated by the policies, as it is sent to an OPA server
+func toInput(input interface{}) (map[string]interface{}, error) {
+	switch in := input.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return in, nil
+	case openpolicyagent.OpaInput:
+		return in.Input, nil
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// prepare returns the prepared query of the rule, preparing it on first use
+func (p *policySet) prepare(ctx context.Context, pkg string, rule string) (*rego.PreparedEvalQuery, error) {
+	ref := ast.DefaultRootRef.Copy()
+	for _, name := range strings.FieldsFunc(pkg, func(r rune) bool { return r == '/' || r == '.' }) {
+		ref = ref.Append(ast.StringTerm(name))
+	}
+	ref = ref.Append(ast.StringTerm(rule))
+	queryString := ref.String()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if query, ok := p.queries[queryString]; ok {
+		return query, nil
+	}
+	query, err := rego.New(
+		rego.Query(queryString),
+		rego.Compiler(p.compiler),
+		rego.Store(p.store),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.queries[queryString] = &query
+	return &query, nil
+}
+
+func loadPolicies(fsys fs.FS, root string, regoVersion ast.RegoVersion) (*policySet, error) {
+	b, err := loader.NewFileLoader().WithFS(fsys).WithRegoVersion(regoVersion).AsBundle(root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load OPA bundle: %w", err)
+	}
+
+	modules := make(map[string]*ast.Module, len(b.Modules))
+	for _, module := range b.Modules {
+		modules[module.Path] = module.Parsed
+	}
+	compiler := ast.NewCompiler().WithDefaultRegoVersion(regoVersion)
+	compiler.Compile(modules)
+	if compiler.Failed() {
+		return nil, fmt.Errorf("unable to compile OPA bundle: %w", compiler.Errors)
+	}
+
+	data := b.Data
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return &policySet{
+		compiler: compiler,
+		store:    inmem.NewFromObject(data),
+		queries:  make(map[string]*rego.PreparedEvalQuery),
+	}, nil
+}
+
+// bundleFingerprint hashes the names and contents of the files of the bundle
+func bundleFingerprint(fsys fs.FS, root string) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		f, err := fsys.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, _ = fmt.Fprintf(h, "%s\x00", path)
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package embedded
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"github.com/open-edge-platform/orch-library/go/pkg/openpolicyagent"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"gotest.tools/assert"
+)
+
+const testPolicy = `package catalog.v1
+
+default allow := false
+
+allow if {
+	input.user in data.catalog.admins
+}
+
+allow if {
+	input.action == "read"
+}
+
+roles contains role if {
+	some role in data.catalog.roles[input.user]
+}
+`
+
+// testPolicyV0 is testPolicy in the Rego syntax of OPA before 1.0
+const testPolicyV0 = `package catalog.v1
+
+default allow = false
+
+allow {
+	input.user == data.catalog.admins[_]
+}
+
+allow {
+	input.action == "read"
+}
+
+roles[role] {
+	role := data.catalog.roles[input.user][_]
+}
+`
+
+const testData = `{"catalog": {"admins": ["alice"], "roles": {"bob": ["reader"]}}}`
+
+type testInput struct {
+	User   string `json:"user"`
+	Action string `json:"action"`
+}
+
+func TestEvaluator(t *testing.T) {
+	bundle := fstest.MapFS{
+		"policies/catalog.rego": {Data: []byte(testPolicy)},
+		"policies/data.json":    {Data: []byte(testData)},
+	}
+	evaluator, err := NewEvaluator(bundle, "policies")
+	assert.NilError(t, err)
+	client := openpolicyagent.NewPolicyClientFromEvaluator(evaluator)
+	ctx := context.Background()
+
+	allowed, err := client.Allow(ctx, "catalog/v1", "allow", testInput{User: "alice", Action: "delete"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	allowed, err = client.Allow(ctx, "catalog.v1", "allow", testInput{User: "bob", Action: "delete"})
+	assert.NilError(t, err)
+	assert.Assert(t, !allowed)
+
+	allowed, err = client.Allow(ctx, "catalog/v1", "allow", map[string]interface{}{"action": "read"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	var roles []string
+	assert.NilError(t, client.Query(ctx, "catalog/v1", "roles", testInput{User: "bob"}, &roles))
+	assert.DeepEqual(t, []string{"reader"}, roles)
+
+	decision, err := client.Evaluate(ctx, "catalog/v1", "missing", nil)
+	assert.NilError(t, err)
+	assert.Assert(t, !decision.Defined())
+	assert.Assert(t, decision.ID != "")
+
+	_, err = client.Allow(ctx, "catalog/v1", "roles", testInput{User: "bob"})
+	assert.Assert(t, errors.IsInvalid(err), err)
+}
+
+func TestEvaluatorRegoV0(t *testing.T) {
+	bundle := fstest.MapFS{
+		"catalog.rego": {Data: []byte(testPolicyV0)},
+		"data.json":    {Data: []byte(testData)},
+	}
+	_, err := NewEvaluator(bundle, ".")
+	assert.ErrorContains(t, err, "rego_parse_error")
+
+	evaluator, err := NewEvaluator(bundle, ".", WithRegoVersion(ast.RegoV0))
+	assert.NilError(t, err)
+	client := openpolicyagent.NewPolicyClientFromEvaluator(evaluator)
+	ctx := context.Background()
+
+	allowed, err := client.Allow(ctx, "catalog/v1", "allow", testInput{User: "alice", Action: "delete"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	allowed, err = client.Allow(ctx, "catalog/v1", "allow", testInput{User: "bob", Action: "delete"})
+	assert.NilError(t, err)
+	assert.Assert(t, !allowed)
+
+	var roles []string
+	assert.NilError(t, client.Query(ctx, "catalog/v1", "roles", testInput{User: "bob"}, &roles))
+	assert.DeepEqual(t, []string{"reader"}, roles)
+}
+
+func TestEvaluatorInvalidBundle(t *testing.T) {
+	_, err := NewEvaluator(fstest.MapFS{
+		"catalog.rego": {Data: []byte("package catalog\n\nallow if { input.user == }\n")},
+	}, ".")
+	assert.ErrorContains(t, err, "rego_parse_error")
+
+	_, err = NewEvaluator(fstest.MapFS{
+		"catalog.rego": {Data: []byte("package catalog\n\nallow if { unknown_function(input) }\n")},
+	}, ".")
+	assert.ErrorContains(t, err, "unable to compile OPA bundle")
+}
+
+func TestEvaluatorReload(t *testing.T) {
+	dir := t.TempDir()
+	writePolicy := func(user string) {
+		policy := "package catalog\n\nallow if input.user == \"" + user + "\"\n"
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, "catalog.rego"), []byte(policy), 0o600))
+	}
+	writePolicy("alice")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, err := NewPolicyClientFromConfig(ctx, openpolicyagent.PolicyConfig{
+		Mode:           openpolicyagent.PolicyModeEmbedded,
+		BundleDir:      dir,
+		ReloadInterval: 10 * time.Millisecond,
+	})
+	assert.NilError(t, err)
+
+	allowed, err := client.Allow(ctx, "catalog", "allow", testInput{User: "alice"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	writePolicy("bob")
+	assert.Assert(t, eventually(func() bool {
+		allowed, err := client.Allow(ctx, "catalog", "allow", testInput{User: "bob"})
+		return err == nil && allowed
+	}))
+
+	// A broken bundle keeps the policies loaded before
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "catalog.rego"), []byte("package catalog\n\nallow if {"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	allowed, err = client.Allow(ctx, "catalog", "allow", testInput{User: "bob"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+}
+
+func TestNewPolicyClientFromConfig(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewPolicyClientFromConfig(ctx, openpolicyagent.PolicyConfig{
+		Mode: openpolicyagent.PolicyModeSidecar, ServerURL: "http://localhost:8181"})
+	assert.NilError(t, err)
+
+	_, err = NewPolicyClientFromConfig(ctx, openpolicyagent.PolicyConfig{})
+	assert.ErrorContains(t, err, "OPA server URL is required")
+
+	_, err = NewPolicyClientFromConfig(ctx, openpolicyagent.PolicyConfig{Mode: openpolicyagent.PolicyModeEmbedded})
+	assert.ErrorContains(t, err, "a bundle directory or file system is required")
+
+	client, err := NewPolicyClientFromConfig(ctx, openpolicyagent.PolicyConfig{
+		Mode:           openpolicyagent.PolicyModeEmbedded,
+		BundleFS:       fstest.MapFS{"catalog.rego": {Data: []byte(testPolicy)}},
+		ReloadInterval: -1,
+	})
+	assert.NilError(t, err)
+	allowed, err := client.Allow(ctx, "catalog/v1", "allow", testInput{Action: "read"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	client, err = NewPolicyClientFromConfig(ctx, openpolicyagent.PolicyConfig{
+		Mode:           openpolicyagent.PolicyModeEmbedded,
+		BundleFS:       fstest.MapFS{"catalog.rego": {Data: []byte(testPolicyV0)}},
+		ReloadInterval: -1,
+		RegoVersion:    openpolicyagent.RegoV0,
+	})
+	assert.NilError(t, err)
+	allowed, err = client.Allow(ctx, "catalog/v1", "allow", testInput{Action: "read"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	_, err = NewPolicyClientFromConfig(ctx, openpolicyagent.PolicyConfig{
+		Mode:        openpolicyagent.PolicyModeEmbedded,
+		BundleFS:    fstest.MapFS{"catalog.rego": {Data: []byte(testPolicy)}},
+		RegoVersion: "v2",
+	})
+	assert.ErrorContains(t, err, "unknown Rego version")
+
+	_, err = NewPolicyClientFromConfig(ctx, openpolicyagent.PolicyConfig{Mode: "wasm"})
+	assert.ErrorContains(t, err, "unknown OPA policy mode")
+}
+
+func eventually(condition func() bool) bool {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,350 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package openpolicyagent
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/open-edge-platform/orch-library/go/dazl"
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var log = dazl.GetLogger()
+
+// Decision is the result of evaluating a rule against an input
+type Decision struct {
+	// ID is the decision_id of the decision log entry, empty if OPA decision logging is disabled
+	ID string
+	// Result is the JSON value of the rule, nil if the rule is undefined for the input
+	Result json.RawMessage
+	// Cached is true if the decision was served from the decision cache
+	Cached bool
+}
+
+// Defined returns true if the rule is defined for the input
+func (d *Decision) Defined() bool {
+	return len(d.Result) > 0 && !bytes.Equal(d.Result, []byte("null"))
+}
+
+// Allowed returns true if the result is the boolean true. An undefined rule or a result
+// of another type is a deny.
+func (d *Decision) Allowed() bool {
+	var allowed bool
+	return d.Defined() && json.Unmarshal(d.Result, &allowed) == nil && allowed
+}
+
+// Evaluator evaluates a rule of a policy package against an input
+type Evaluator interface {
+	// Evaluate evaluates the rule, returning typed errors from the errors package on failure
+	Evaluate(ctx context.Context, pkg string, rule string, input interface{}) (*Decision, error)
+}
+
+// DecisionObserver is called with every decision, e.g. to write audit logs carrying the decision ID
+type DecisionObserver func(ctx context.Context, pkg string, rule string, decision *Decision)
+
+// PolicyClientOption configures a PolicyClient
+type PolicyClientOption func(*PolicyClient)
+
+// WithDecisionCache caches up to size decisions for ttl, keyed by the package, the rule and a hash of
+// the input. The least recently used decision is evicted when the cache is full. Only use it for
+// policies that do not depend on data changing faster than the ttl.
+func WithDecisionCache(size int, ttl time.Duration) PolicyClientOption {
+	return func(c *PolicyClient) {
+		if size > 0 && ttl > 0 {
+			c.cache = newDecisionCache(size, ttl)
+		}
+	}
+}
+
+// WithDecisionObserver sets a function called with every decision, including cached ones
+func WithDecisionObserver(observer DecisionObserver) PolicyClientOption {
+	return func(c *PolicyClient) {
+		c.observer = observer
+	}
+}
+
+// PolicyClient queries policies with typed results and errors
+type PolicyClient struct {
+	evaluator        Evaluator
+	cache            *decisionCache
+	observer         DecisionObserver
+	batchConcurrency int
+}
+
+var _ Evaluator = &PolicyClient{}
+
+// NewPolicyClient creates a policy client querying an OPA server, e.g. a sidecar, with the generated client.
+// Batches are evaluated with single queries; see NewServerEvaluator and WithQueryClient for batch queries.
+func NewPolicyClient(client ClientWithResponsesInterface, opts ...PolicyClientOption) *PolicyClient {
+	return NewPolicyClientFromEvaluator(NewServerEvaluator(client), opts...)
+}
+
+// NewPolicyClientFromEvaluator creates a policy client querying policies with the given evaluator
+func NewPolicyClientFromEvaluator(evaluator Evaluator, opts ...PolicyClientOption) *PolicyClient {
+	c := &PolicyClient{
+		evaluator: evaluator,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Evaluate evaluates the rule against the input, using the decision cache if enabled
+func (c *PolicyClient) Evaluate(ctx context.Context, pkg string, rule string, input interface{}) (*Decision, error) {
+	var key string
+	if c.cache != nil {
+		var err error
+		key, err = decisionKey(pkg, rule, input)
+		if err != nil {
+			return nil, errors.NewInvalid("unable to encode input of %s/%s: %v", pkg, rule, err)
+		}
+		if decision, ok := c.cache.get(key); ok {
+			c.observe(ctx, pkg, rule, decision)
+			return decision, nil
+		}
+	}
+
+	decision, err := c.evaluator.Evaluate(ctx, pkg, rule, input)
+	if err != nil {
+		return nil, err
+	}
+	if c.cache != nil {
+		c.cache.put(key, decision)
+	}
+	c.observe(ctx, pkg, rule, decision)
+	return decision, nil
+}
+
+// Allow evaluates a boolean rule. An undefined rule is a deny; a rule of another type is an Invalid error.
+func (c *PolicyClient) Allow(ctx context.Context, pkg string, rule string, input interface{}) (bool, error) {
+	decision, err := c.Evaluate(ctx, pkg, rule, input)
+	if err != nil {
+		return false, err
+	}
+	if !decision.Defined() {
+		return false, nil
+	}
+	var allowed bool
+	if err := json.Unmarshal(decision.Result, &allowed); err != nil {
+		return false, errors.NewInvalid("result of %s/%s is not a boolean", pkg, rule)
+	}
+	return allowed, nil
+}
+
+// Query evaluates a rule and decodes its result into out. An undefined rule is a NotFound error;
+// a result that cannot be decoded into out is an Invalid error.
+func (c *PolicyClient) Query(ctx context.Context, pkg string, rule string, input interface{}, out interface{}) error {
+	decision, err := c.Evaluate(ctx, pkg, rule, input)
+	if err != nil {
+		return err
+	}
+	if !decision.Defined() {
+		return errors.NewNotFound("rule %s/%s is undefined", pkg, rule)
+	}
+	if err := json.Unmarshal(decision.Result, out); err != nil {
+		return errors.NewInvalid("unable to decode result of %s/%s: %v", pkg, rule, err)
+	}
+	return nil
+}
+
+func (c *PolicyClient) observe(ctx context.Context, pkg string, rule string, decision *Decision) {
+	if c.observer != nil {
+		c.observer(ctx, pkg, rule, decision)
+		return
+	}
+	log.Debugf("OPA decision %s for %s/%s (cached %t)", decision.ID, pkg, rule, decision.Cached)
+}
+
+// ServerEvaluator evaluates rules with the data API of an OPA server
+type ServerEvaluator struct {
+	client           ClientWithResponsesInterface
+	queryClient      *Client
+	batchUnsupported atomic.Bool
+}
+
+var _ Evaluator = &ServerEvaluator{}
+
+// NewServerEvaluator creates an evaluator querying an OPA server with the generated client
+func NewServerEvaluator(client ClientWithResponsesInterface, opts ...ServerEvaluatorOption) *ServerEvaluator {
+	e := &ServerEvaluator{client: client}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Evaluate posts the input to the rule of the package
+func (e *ServerEvaluator) Evaluate(ctx context.Context, pkg string, rule string, input interface{}) (*Decision, error) {
+	opaInput, err := toInput(input)
+	if err != nil {
+		return nil, errors.NewInvalid("unable to encode input of %s/%s: %v", pkg, rule, err)
+	}
+
+	resp, err := e.client.PostV1DataPackageRuleWithResponse(ctx, pkg, rule, nil, opaInput)
+	if err != nil {
+		switch ctx.Err() {
+		case context.Canceled:
+			return nil, errors.NewCanceled("query of %s/%s canceled", pkg, rule)
+		case context.DeadlineExceeded:
+			return nil, errors.NewTimeout("query of %s/%s timed out", pkg, rule)
+		}
+		return nil, errors.NewUnavailable("unable to query %s/%s: %v", pkg, rule, err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, responseError(resp, fmt.Sprintf("query of %s/%s failed", pkg, rule))
+	}
+	if resp.JSON200 == nil {
+		return nil, errors.NewInternal("unable to decode result of %s/%s", pkg, rule)
+	}
+
+	result, err := resp.JSON200.Result.MarshalJSON()
+	if err != nil {
+		return nil, errors.NewInternal("unable to decode result of %s/%s: %v", pkg, rule, err)
+	}
+	decision := &Decision{Result: result}
+	if resp.JSON200.DecisionId != nil {
+		decision.ID = *resp.JSON200.DecisionId
+	}
+	return decision, nil
+}
+
+// toInput converts the input to the generic map sent to OPA
+func toInput(input interface{}) (OpaInput, error) {
+	switch in := input.(type) {
+	case nil:
+		return OpaInput{Input: map[string]interface{}{}}, nil
+	case map[string]interface{}:
+		return OpaInput{Input: in}, nil
+	case OpaInput:
+		return in, nil
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return OpaInput{}, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return OpaInput{}, err
+	}
+	return OpaInput{Input: m}, nil
+}
+
+// responseError maps a failed OPA response to a typed error
+func responseError(resp *PostV1DataPackageRuleResponse, msg string) error {
+	msg = fmt.Sprintf("%s %d", msg, resp.StatusCode())
+	if opaErr := resp.JSONDefault; opaErr != nil {
+		switch {
+		case opaErr.Message != nil && opaErr.Code != nil:
+			msg = fmt.Sprintf("%s: %s: %s", msg, *opaErr.Code, *opaErr.Message)
+		case opaErr.Message != nil:
+			msg = fmt.Sprintf("%s: %s", msg, *opaErr.Message)
+		case opaErr.Error != nil:
+			msg = fmt.Sprintf("%s: %s", msg, *opaErr.Error)
+		}
+	}
+	switch resp.StatusCode() {
+	case http.StatusBadRequest:
+		return errors.NewInvalid("%s", msg)
+	case http.StatusUnauthorized:
+		return errors.NewUnauthorized("%s", msg)
+	case http.StatusForbidden:
+		return errors.NewForbidden("%s", msg)
+	case http.StatusNotFound:
+		return errors.NewNotFound("%s", msg)
+	case http.StatusInternalServerError:
+		// OPA answers 500 to evaluation errors, e.g. conflicting rule values
+		return errors.NewInternal("%s", msg)
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return errors.NewUnavailable("%s", msg)
+	}
+	return errors.NewUnknown("%s", msg)
+}
+
+// decisionKey hashes the package, rule and input. JSON objects are encoded with sorted keys,
+// so equal inputs have equal keys.
+func decisionKey(pkg string, rule string, input interface{}) (string, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s/%s\x00", pkg, rule)
+	_, _ = h.Write(raw)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type decisionCacheEntry struct {
+	key      string
+	decision Decision
+	expires  time.Time
+}
+
+// decisionCache is an LRU cache of decisions expiring after a TTL
+type decisionCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries *list.List
+	items   map[string]*list.Element
+}
+
+func newDecisionCache(size int, ttl time.Duration) *decisionCache {
+	return &decisionCache{
+		size:    size,
+		ttl:     ttl,
+		entries: list.New(),
+		items:   make(map[string]*list.Element, size),
+	}
+}
+
+func (c *decisionCache) get(key string) (*Decision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*decisionCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.entries.Remove(elem)
+		delete(c.items, key)
+		return nil, false
+	}
+	c.entries.MoveToFront(elem)
+	decision := entry.decision
+	decision.Cached = true
+	return &decision, true
+}
+
+func (c *decisionCache) put(key string, decision *Decision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &decisionCacheEntry{
+		key:      key,
+		decision: *decision,
+		expires:  time.Now().Add(c.ttl),
+	}
+	if elem, ok := c.items[key]; ok {
+		elem.Value = entry
+		c.entries.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.entries.PushFront(entry)
+	for c.entries.Len() > c.size {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.items, oldest.Value.(*decisionCacheEntry).key)
+	}
+}
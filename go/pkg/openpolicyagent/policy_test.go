@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: (C) 2025 Intel Corporation
+// SPDX-License-Identifier: Apache-2.0
+
+package openpolicyagent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/open-edge-platform/orch-library/go/pkg/errors"
+	"gotest.tools/assert"
+)
+
+// newTestServer serves the OPA data API, answering with the response returned by handle
+func newTestServer(t *testing.T, handle func(path string, input map[string]interface{}) (int, string)) (*ClientWithResponses, *atomic.Int32) {
+	calls := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var body OpaInput
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+		statusCode, response := handle(r.URL.Path, body.Input)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClientWithResponses(server.URL)
+	assert.NilError(t, err)
+	return client, calls
+}
+
+type testInput struct {
+	User   string `json:"user"`
+	Action string `json:"action"`
+}
+
+func TestPolicyClientAllow(t *testing.T) {
+	opaClient, _ := newTestServer(t, func(path string, input map[string]interface{}) (int, string) {
+		switch path {
+		case "/v1/data/catalog/allow":
+			if input["user"] == "alice" {
+				return http.StatusOK, `{"decision_id": "d-1", "result": true}`
+			}
+			return http.StatusOK, `{"decision_id": "d-2", "result": false}`
+		case "/v1/data/catalog/undefined":
+			return http.StatusOK, `{"decision_id": "d-3"}`
+		case "/v1/data/catalog/roles":
+			return http.StatusOK, `{"result": {"roles": ["reader"]}}`
+		}
+		return http.StatusNotFound, `{}`
+	})
+	client := NewPolicyClient(opaClient)
+	ctx := context.Background()
+
+	allowed, err := client.Allow(ctx, "catalog", "allow", testInput{User: "alice", Action: "read"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowed)
+
+	allowed, err = client.Allow(ctx, "catalog", "allow", map[string]interface{}{"user": "bob"})
+	assert.NilError(t, err)
+	assert.Assert(t, !allowed)
+
+	allowed, err = client.Allow(ctx, "catalog", "undefined", nil)
+	assert.NilError(t, err)
+	assert.Assert(t, !allowed)
+
+	_, err = client.Allow(ctx, "catalog", "roles", nil)
+	assert.Assert(t, errors.IsInvalid(err), err)
+
+	decision, err := client.Evaluate(ctx, "catalog", "allow", testInput{User: "alice"})
+	assert.NilError(t, err)
+	assert.Equal(t, "d-1", decision.ID)
+	assert.Assert(t, decision.Defined())
+	assert.Assert(t, decision.Allowed())
+
+	for _, rule := range []string{"undefined", "roles"} {
+		decision, err = client.Evaluate(ctx, "catalog", rule, nil)
+		assert.NilError(t, err)
+		assert.Assert(t, !decision.Allowed(), rule)
+	}
+}
+
+func TestPolicyClientQuery(t *testing.T) {
+	opaClient, _ := newTestServer(t, func(path string, _ map[string]interface{}) (int, string) {
+		if path == "/v1/data/catalog/roles" {
+			return http.StatusOK, `{"result": {"roles": ["reader", "writer"]}}`
+		}
+		return http.StatusOK, `{}`
+	})
+	client := NewPolicyClient(opaClient)
+	ctx := context.Background()
+
+	var out struct {
+		Roles []string `json:"roles"`
+	}
+	assert.NilError(t, client.Query(ctx, "catalog", "roles", nil, &out))
+	assert.DeepEqual(t, []string{"reader", "writer"}, out.Roles)
+
+	err := client.Query(ctx, "catalog", "missing", nil, &out)
+	assert.Assert(t, errors.IsNotFound(err), err)
+
+	var wrongType bool
+	err = client.Query(ctx, "catalog", "roles", nil, &wrongType)
+	assert.Assert(t, errors.IsInvalid(err), err)
+}
+
+func TestPolicyClientErrors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statusCode int
+		body       string
+		check      func(error) bool
+	}{
+		{
+			name:       "invalid query",
+			statusCode: http.StatusBadRequest,
+			body:       `{"code": "invalid_parameter", "message": "error(s) occurred while parsing query"}`,
+			check:      errors.IsInvalid,
+		},
+		{
+			name:       "unauthorized",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"code": "unauthorized", "message": "missing token"}`,
+			check:      errors.IsUnauthorized,
+		},
+		{
+			name:       "evaluation error",
+			statusCode: http.StatusInternalServerError,
+			body:       `{"code": "internal_error", "message": "eval_conflict_error"}`,
+			check:      errors.IsInternal,
+		},
+		{
+			name:       "unavailable",
+			statusCode: http.StatusServiceUnavailable,
+			body:       `{}`,
+			check:      errors.IsUnavailable,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opaClient, _ := newTestServer(t, func(string, map[string]interface{}) (int, string) {
+				return tc.statusCode, tc.body
+			})
+			_, err := NewPolicyClient(opaClient).Allow(context.Background(), "catalog", "allow", nil)
+			assert.Assert(t, tc.check(err), err)
+		})
+	}
+
+	opaClient, err := NewClientWithResponses("http://127.0.0.1:1")
+	assert.NilError(t, err)
+	_, err = NewPolicyClient(opaClient).Allow(context.Background(), "catalog", "allow", nil)
+	assert.Assert(t, errors.IsUnavailable(err), err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewPolicyClient(opaClient).Allow(ctx, "catalog", "allow", nil)
+	assert.Assert(t, errors.IsCanceled(err), err)
+}
+
+func TestPolicyClientDecisionCache(t *testing.T) {
+	opaClient, calls := newTestServer(t, func(string, map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"decision_id": "d-1", "result": true}`
+	})
+	var observed []*Decision
+	client := NewPolicyClient(opaClient, WithDecisionCache(2, 50*time.Millisecond),
+		WithDecisionObserver(func(_ context.Context, _ string, _ string, decision *Decision) {
+			observed = append(observed, decision)
+		}))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		allowed, err := client.Allow(ctx, "catalog", "allow", testInput{User: "alice"})
+		assert.NilError(t, err)
+		assert.Assert(t, allowed)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, 3, len(observed))
+	assert.Assert(t, !observed[0].Cached)
+	assert.Assert(t, observed[2].Cached)
+	assert.Equal(t, "d-1", observed[2].ID)
+
+	// Different inputs and rules have different keys, and the least recently used is evicted
+	_, err := client.Allow(ctx, "catalog", "allow", testInput{User: "bob"})
+	assert.NilError(t, err)
+	_, err = client.Allow(ctx, "catalog", "other", testInput{User: "alice"})
+	assert.NilError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	_, err = client.Allow(ctx, "catalog", "allow", testInput{User: "alice"})
+	assert.NilError(t, err)
+	assert.Equal(t, int32(4), calls.Load())
+
+	// Decisions expire after the TTL
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.Allow(ctx, "catalog", "allow", testInput{User: "alice"})
+	assert.NilError(t, err)
+	assert.Equal(t, int32(5), calls.Load())
+}
+
+func TestNewPolicyClientFromConfig(t *testing.T) {
+	_, err := NewPolicyClientFromConfig(PolicyConfig{Mode: PolicyModeSidecar, ServerURL: "http://localhost:8181"})
+	assert.NilError(t, err)
+
+	_, err = NewPolicyClientFromConfig(PolicyConfig{})
+	assert.ErrorContains(t, err, "OPA server URL is required")
+
+	_, err = NewPolicyClientFromConfig(PolicyConfig{Mode: PolicyModeEmbedded, BundleDir: "policies"})
+	assert.ErrorContains(t, err, "requires the openpolicyagent/embedded module")
+
+	_, err = NewPolicyClientFromConfig(PolicyConfig{Mode: "wasm"})
+	assert.ErrorContains(t, err, "unknown OPA policy mode")
+}